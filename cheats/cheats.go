@@ -0,0 +1,154 @@
+// Package cheats implements Game Genie and GameShark codes.  Game Genie codes patch ROM as it is read through the
+// MMU, GameShark codes write RAM once per frame at VBlank.
+package cheats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// Cheat is a code that has been added to an Engine
+type Cheat struct {
+	Code        string
+	Description string
+	Enabled     bool
+
+	genie *GameGenie
+	shark *GameShark
+}
+
+// Engine holds the cheats for a cartridge.  It is not safe for concurrent use, it must be used from the goroutine that
+// runs the emulator.
+type Engine struct {
+	cheats []*Cheat
+}
+
+func New() *Engine {
+	return &Engine{}
+}
+
+// Add decodes a Game Genie or GameShark code and enables it
+func (e *Engine) Add(code, description string) (*Cheat, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	cheat := &Cheat{
+		Code:        code,
+		Description: description,
+		Enabled:     true,
+	}
+
+	if len(code) == 8 && !strings.Contains(code, "-") {
+		shark, err := DecodeGameShark(code)
+		if err != nil {
+			return nil, err
+		}
+		cheat.shark = &shark
+	} else {
+		genie, err := DecodeGameGenie(code)
+		if err != nil {
+			return nil, err
+		}
+		cheat.genie = &genie
+	}
+
+	e.cheats = append(e.cheats, cheat)
+	return cheat, nil
+}
+
+// Remove removes a code, it returns false if the code was not added
+func (e *Engine) Remove(code string) bool {
+	for i, cheat := range e.cheats {
+		if strings.EqualFold(cheat.Code, code) {
+			e.cheats = append(e.cheats[:i], e.cheats[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Enable enables or disables a code without removing it
+func (e *Engine) Enable(code string, enabled bool) error {
+	for _, cheat := range e.cheats {
+		if strings.EqualFold(cheat.Code, code) {
+			cheat.Enabled = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("cheat %q has not been added", code)
+}
+
+// Cheats returns all codes that have been added
+func (e *Engine) Cheats() []*Cheat {
+	return e.cheats
+}
+
+// Intercept implements mmu.Interceptor, it applies the enabled Game Genie codes to reads from ROM
+func (e *Engine) Intercept(a uint16, v uint8) uint8 {
+	if a >= 0x8000 {
+		return v
+	}
+
+	for _, cheat := range e.cheats {
+		if !cheat.Enabled || cheat.genie == nil {
+			continue
+		}
+		if patched, ok := cheat.genie.patch(a, v); ok {
+			return patched
+		}
+	}
+	return v
+}
+
+// Apply writes the values of the enabled GameShark codes, it is called once per frame at VBlank
+func (e *Engine) Apply(ram mmu.ReadWriter) {
+	for _, cheat := range e.cheats {
+		if !cheat.Enabled || cheat.shark == nil {
+			continue
+		}
+		// The WRAM bank of 0x9x codes is ignored since WRAM banking is not emulated
+		ram.Write(cheat.shark.Address, cheat.shark.Value)
+	}
+}
+
+// Load reads codes from a cheat file.  Each line holds a code optionally followed by a description, lines starting
+// with # are comments and a code prefixed with ! is added disabled.
+func (e *Engine) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		enabled := !strings.HasPrefix(text, "!")
+		text = strings.TrimPrefix(text, "!")
+
+		fields := strings.SplitN(text, " ", 2)
+		description := ""
+		if len(fields) == 2 {
+			description = strings.TrimSpace(fields[1])
+		}
+
+		cheat, err := e.Add(fields[0], description)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		cheat.Enabled = enabled
+	}
+	return scanner.Err()
+}
+
+// LoadFile reads codes from the cheat file at path
+func (e *Engine) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return e.Load(f)
+}
@@ -0,0 +1,81 @@
+package cheats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+func TestCheats(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "DecodeGameGenie",
+			test: func(t *testing.T) {
+				g, err := DecodeGameGenie("00A-17B-C49")
+				require.NoError(t, err)
+				require.EqualValues(t, 0x4a17, g.Address)
+				require.EqualValues(t, 0x00, g.Value)
+				require.True(t, g.HasCompare)
+				require.EqualValues(t, 0xc8, g.Compare)
+
+				g, err = DecodeGameGenie("01B-2CE")
+				require.NoError(t, err)
+				require.EqualValues(t, 0x1b2c, g.Address)
+				require.EqualValues(t, 0x01, g.Value)
+				require.False(t, g.HasCompare)
+
+				_, err = DecodeGameGenie("01B-2C")
+				require.Error(t, err)
+
+				// Only ROM addresses can be patched
+				_, err = DecodeGameGenie("01B-2C7")
+				require.EqualError(t, err, `game genie code "01B-2C7" patches 0x8b2c which is outside of ROM`)
+			},
+		},
+		{
+			name: "DecodeGameShark",
+			test: func(t *testing.T) {
+				g, err := DecodeGameShark("010538CD")
+				require.NoError(t, err)
+				require.EqualValues(t, 0x01, g.Type)
+				require.EqualValues(t, 0x05, g.Value)
+				require.EqualValues(t, 0xcd38, g.Address)
+
+				_, err = DecodeGameShark("020538CD")
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "Engine",
+			test: func(t *testing.T) {
+				e := New()
+				require.NoError(t, e.Load(strings.NewReader("# comment\n01B-2CE infinite lives\n!010538CD\n")))
+				require.Len(t, e.Cheats(), 2)
+
+				require.EqualValues(t, 0x01, e.Intercept(0x1b2c, 0x99))
+				require.EqualValues(t, 0x99, e.Intercept(0x1b2d, 0x99))
+
+				ram := make(mmu.RAM, 0x10000)
+				e.Apply(ram)
+				require.EqualValues(t, 0x00, ram[0xcd38])
+
+				require.NoError(t, e.Enable("010538cd", true))
+				e.Apply(ram)
+				require.EqualValues(t, 0x05, ram[0xcd38])
+
+				require.True(t, e.Remove("01B-2CE"))
+				require.EqualValues(t, 0x99, e.Intercept(0x1b2c, 0x99))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -0,0 +1,64 @@
+package cheats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GameGenie is a decoded Game Genie code, it replaces a byte of ROM.  When HasCompare is set the byte is only
+// replaced if the ROM contains Compare, which keeps the patch from applying to the wrong ROM bank.
+// See: https://gbdev.gg8.se/wiki/articles/Gameboy_Game_Genie_Codes
+type GameGenie struct {
+	Address    uint16
+	Value      uint8
+	Compare    uint8
+	HasCompare bool
+}
+
+// DecodeGameGenie decodes a code in the ABC-DEF or ABC-DEF-GHI format
+func DecodeGameGenie(code string) (GameGenie, error) {
+	digits := strings.ReplaceAll(code, "-", "")
+	if len(digits) != 6 && len(digits) != 9 {
+		return GameGenie{}, fmt.Errorf("game genie code %q must have 6 or 9 digits", code)
+	}
+
+	d := make([]uint8, len(digits))
+	for i, c := range digits {
+		v, err := strconv.ParseUint(string(c), 16, 8)
+		if err != nil {
+			return GameGenie{}, fmt.Errorf("game genie code %q is not hexadecimal", code)
+		}
+		d[i] = uint8(v)
+	}
+
+	// AB is the new value, FCDE is the address with the top nibble inverted
+	g := GameGenie{
+		Value:   d[0]<<4 | d[1],
+		Address: uint16(d[5]^0xf)<<12 | uint16(d[2])<<8 | uint16(d[3])<<4 | uint16(d[4]),
+	}
+
+	// The Game Genie sits between the cartridge and the Game Boy, so it can only patch reads from ROM
+	if g.Address >= 0x8000 {
+		return GameGenie{}, fmt.Errorf("game genie code %q patches %#04x which is outside of ROM", code, g.Address)
+	}
+
+	if len(d) == 9 {
+		// GI is the encoded compare value, it is decoded by rotating it right by two and XORing it with 0xba.  H is not
+		// used.
+		c := d[6]<<4 | d[8]
+		c = c>>2 | c<<6
+		g.Compare = c ^ 0xba
+		g.HasCompare = true
+	}
+
+	return g, nil
+}
+
+// patch returns the value to read from ROM at a, and whether the code applies
+func (g GameGenie) patch(a uint16, v uint8) (uint8, bool) {
+	if a != g.Address || (g.HasCompare && v != g.Compare) {
+		return v, false
+	}
+	return g.Value, true
+}
@@ -0,0 +1,44 @@
+package cheats
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GameShark is a decoded GameShark code, it writes a byte of RAM once per frame
+// See: https://gbdev.gg8.se/wiki/articles/Gameboy_GameShark_Codes
+type GameShark struct {
+	// Type is 0x01 for a normal write, 0x90 - 0x97 select the WRAM bank on the CGB
+	Type    uint8
+	Value   uint8
+	Address uint16
+}
+
+// DecodeGameShark decodes a code in the ABCDEFGH format, where AB is the type, CD the value and GHEF the address
+func DecodeGameShark(code string) (GameShark, error) {
+	if len(code) != 8 {
+		return GameShark{}, fmt.Errorf("gameshark code %q must have 8 digits", code)
+	}
+
+	v, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return GameShark{}, fmt.Errorf("gameshark code %q is not hexadecimal", code)
+	}
+
+	g := GameShark{
+		Type:    uint8(v >> 24),
+		Value:   uint8(v >> 16),
+		Address: uint16(v&0xff)<<8 | uint16(v>>8&0xff),
+	}
+
+	if g.Type != 0x01 && (g.Type < 0x90 || g.Type > 0x97) {
+		return GameShark{}, fmt.Errorf("gameshark code %q has unsupported type %#02x", code, g.Type)
+	}
+
+	// Only external and work RAM can be written, anything else would be overwritten by the game or crash it
+	if g.Address < 0xa000 || g.Address > 0xdfff {
+		return GameShark{}, fmt.Errorf("gameshark code %q writes %#04x which is not in RAM", code, g.Address)
+	}
+
+	return g, nil
+}
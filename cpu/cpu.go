@@ -41,9 +41,11 @@ type CPU struct {
 	sp Register
 	pc Register
 
-	halt   bool
-	cycles uint64
-	ram    mmu.ReadWriter
+	halt    bool
+	stopped bool
+	speed   Speed
+	cycles  uint64
+	ram     mmu.ReadWriter
 }
 
 // initFlags set
@@ -76,6 +78,17 @@ func (c *CPU) setDecFlags(v uint8) {
 	c.enableFlag(flagSubtraction)
 }
 
+// Registers holds the values of the CPU registers, it is used to start the CPU in a known state
+type Registers struct {
+	AF uint16
+	BC uint16
+	DE uint16
+	HL uint16
+	SP uint16
+	PC uint16
+}
+
+// New returns a CPU in the state that the DMG boot ROM leaves it in
 func New(ram mmu.ReadWriter) *CPU {
 	return &CPU{
 		af: 0x01b0,
@@ -92,6 +105,17 @@ func New(ram mmu.ReadWriter) *CPU {
 	}
 }
 
+// SetRegisters replaces the contents of all registers.  A zero Registers is the power-on state used when running a
+// boot ROM.
+func (c *CPU) SetRegisters(r Registers) {
+	c.af = Register(r.AF)
+	c.bc = Register(r.BC)
+	c.de = Register(r.DE)
+	c.hl = Register(r.HL)
+	c.sp = Register(r.SP)
+	c.pc = Register(r.PC)
+}
+
 type flag uint8
 type interrupt uint8
 
@@ -112,12 +136,33 @@ const (
 
 // Next runs a single iteration of the CPU and returns the number of cycles taken
 func (c *CPU) Next() uint8 {
+	if c.stopped {
+		// The CPU is idle until it is woken up by Wake, time still passes
+		c.cycles++
+		return 1
+	}
+
 	opCode := c.PC()
 	// Break the op code into two parts to look up from our map
-	instruction := instructionsByOpcode[opCode&0xf0][opCode&0x0f]
+	instruction := instructionsByOpcode[opCode>>4][opCode&0x0f]
 	return c.exec(instruction)
 }
 
+// Wake resumes a CPU that was stopped by STOP, on hardware this happens when a button is pressed
+func (c *CPU) Wake() {
+	c.stopped = false
+}
+
+// Speed returns the KEY1 speed switch register so that it can be mapped into memory
+func (c *CPU) Speed() *Speed {
+	return &c.speed
+}
+
+// DoubleSpeed reports if the CPU is running at 8 MiHz, in which case each cycle takes half as long as in normal speed
+func (c *CPU) DoubleSpeed() bool {
+	return c.speed.Double()
+}
+
 func (c *CPU) exec(instruction instructionFunc) uint8 {
 	cycles := instruction(c)
 	c.cycles = c.cycles + uint64(cycles)
@@ -8,8 +8,8 @@ type instructionFunc func(c *CPU) uint8
 var instructionsByOpcode = [][]instructionFunc{
 	// 0x00 - 0x0f
 	{nop, ldBcD16, ldBcA, incBc, incB, decB, ldBD8}, // rlca, ldA16Sp, addHlBc, ldABc, decBc, incC, decC, ldCD8, rrca},
-	// 0x01 - 0x1f
-	//{STOP, LD_DE_d16, LD_DE_A, INC_DE, INC_D, DEC_D, LD_D_d8, RLA, JR_s8, ADD_HL_DE, LD_A_DE, DEC_DE, INC_E, DEC_E, LD_E_d8, RRA},
+	// 0x10 - 0x1f
+	{stop}, // ldDeD16, ldDeA, incDe, incD, decD, ldDD8, rla, jrS8, addHlDe, ldADe, decDe, incE, decE, ldED8, rra},
 }
 
 // ----- common helpers -----
@@ -91,6 +91,20 @@ func ldBD8(c *CPU) uint8 {
 	return 1
 }
 
+// stop performs a speed switch if one has been armed through KEY1, otherwise it stops the CPU
+func stop(c *CPU) uint8 {
+	// STOP is encoded as two bytes, the second is ignored
+	c.PC()
+
+	// The speed switch pauses the CPU for 2050 cycles on hardware, this is not emulated
+	if c.speed.toggle() {
+		return 1
+	}
+
+	c.stopped = true
+	return 1
+}
+
 //		//
 //		//// LD B, d8
 //		//0x06: func(c *CPU) uint8 {
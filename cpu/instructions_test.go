@@ -105,6 +105,53 @@ func TestInstructions(t *testing.T) {
 				require.EqualValues(t, 1, c.cycles)
 			},
 		},
+		{
+			name:   "stop",
+			memory: mmu.RAM{0x00, 0x00},
+			test: func(t *testing.T, c *CPU) {
+				c.exec(stop)
+				require.True(t, c.stopped)
+				require.False(t, c.DoubleSpeed())
+				require.EqualValues(t, 1, c.pc)
+				require.EqualValues(t, 1, c.cycles)
+			},
+		},
+		{
+			name:   "stopSpeedSwitch",
+			memory: mmu.RAM{0x00, 0x00, 0x00, 0x00},
+			test: func(t *testing.T, c *CPU) {
+				c.Speed().Write(0xff4d, 0x01)
+				require.EqualValues(t, 0x7f, c.Speed().Read(0xff4d))
+
+				c.exec(stop)
+				require.False(t, c.stopped)
+				require.True(t, c.DoubleSpeed())
+				require.EqualValues(t, 0xfe, c.Speed().Read(0xff4d))
+
+				// Without arming the switch again STOP stops the CPU
+				c.exec(stop)
+				require.True(t, c.stopped)
+				require.True(t, c.DoubleSpeed())
+			},
+		},
+		{
+			name:   "stopWake",
+			memory: mmu.RAM{0x00, 0x00, 0x00},
+			test: func(t *testing.T, c *CPU) {
+				c.exec(stop)
+				require.True(t, c.stopped)
+
+				// The CPU stays stopped until it is woken up
+				require.EqualValues(t, 1, c.Next())
+				require.True(t, c.stopped)
+				require.EqualValues(t, 1, c.pc)
+
+				c.Wake()
+				c.Next()
+				require.False(t, c.stopped)
+				require.EqualValues(t, 2, c.pc)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,41 @@
+package cpu
+
+// Speed implements the CGB KEY1 register (0xff4d) which is used to switch the CPU between normal speed (4 MiHz) and
+// double speed (8 MiHz).  A switch is armed by writing bit 0 and then performed by executing STOP.
+// See: https://gbdev.io/pandocs/CGB_Registers.html#ff4d--key1-cgb-mode-only-prepare-speed-switch
+type Speed struct {
+	double bool
+	armed  bool
+}
+
+// Read returns the current speed in bit 7 and the armed state in bit 0, the unused bits read back as set
+func (s *Speed) Read(a uint16) uint8 {
+	v := uint8(0x7e)
+	if s.double {
+		v |= 0x80
+	}
+	if s.armed {
+		v |= 0x01
+	}
+	return v
+}
+
+// Write arms or disarms a speed switch, only bit 0 is writable
+func (s *Speed) Write(a uint16, v uint8) {
+	s.armed = v&0x01 != 0
+}
+
+// Double reports if the CPU is running in double speed mode
+func (s *Speed) Double() bool {
+	return s.double
+}
+
+// toggle performs an armed speed switch, it returns false if no switch was armed
+func (s *Speed) toggle() bool {
+	if !s.armed {
+		return false
+	}
+	s.double = !s.double
+	s.armed = false
+	return true
+}
@@ -0,0 +1,131 @@
+// Package dat identifies ROMs by looking up their hashes in No-Intro DAT files, which use the Logiqx XML format.
+package dat
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Game is an entry of a DAT file
+type Game struct {
+	// Name is the canonical name, for example "Tetris (World) (Rev 1)"
+	Name string `json:"name"`
+	// Region is taken from the first parenthesized part of the name, for example "World" or "USA, Europe"
+	Region string `json:"region"`
+	// Status is "verified" for dumps that have been confirmed, "baddump" for known bad dumps, or empty
+	Status string `json:"status,omitempty"`
+
+	Size  int    `json:"size"`
+	CRC32 uint32 `json:"crc32"`
+	SHA1  string `json:"sha1"`
+}
+
+// Verified reports if the dump has been confirmed to be good
+func (g *Game) Verified() bool {
+	return g.Status == "verified"
+}
+
+// BadDump reports if the dump is known to be bad
+func (g *Game) BadDump() bool {
+	return g.Status == "baddump"
+}
+
+// DAT is a set of games loaded from one or more DAT files
+type DAT struct {
+	byCRC  map[uint32]*Game
+	bySHA1 map[string]*Game
+}
+
+func New() *DAT {
+	return &DAT{
+		byCRC:  map[uint32]*Game{},
+		bySHA1: map[string]*Game{},
+	}
+}
+
+// datafile is the XML layout of a DAT file
+type datafile struct {
+	Games []struct {
+		Name string `xml:"name,attr"`
+		ROMs []struct {
+			Size   int    `xml:"size,attr"`
+			CRC    string `xml:"crc,attr"`
+			SHA1   string `xml:"sha1,attr"`
+			Status string `xml:"status,attr"`
+		} `xml:"rom"`
+	} `xml:"game"`
+}
+
+// Load adds the games of a DAT file
+func (d *DAT) Load(r io.Reader) error {
+	var f datafile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return err
+	}
+
+	for _, g := range f.Games {
+		for _, rom := range g.ROMs {
+			crc, err := strconv.ParseUint(rom.CRC, 16, 32)
+			if err != nil {
+				return fmt.Errorf("game %q has invalid crc %q", g.Name, rom.CRC)
+			}
+
+			game := &Game{
+				Name:   g.Name,
+				Region: region(g.Name),
+				Status: rom.Status,
+				Size:   rom.Size,
+				CRC32:  uint32(crc),
+				SHA1:   strings.ToLower(rom.SHA1),
+			}
+			d.byCRC[game.CRC32] = game
+			if game.SHA1 != "" {
+				d.bySHA1[game.SHA1] = game
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFile adds the games of the DAT file at path
+func (d *DAT) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return d.Load(f)
+}
+
+// Lookup identifies a ROM, matching by SHA1 and falling back to CRC32 for DAT files without SHA1 hashes
+func (d *DAT) Lookup(rom []byte) (*Game, bool) {
+	sum := sha1.Sum(rom)
+	if game, ok := d.bySHA1[hex.EncodeToString(sum[:])]; ok {
+		return game, true
+	}
+
+	game, ok := d.byCRC[crc32.ChecksumIEEE(rom)]
+	if ok && game.SHA1 != "" {
+		// The DAT has a SHA1 for this game that did not match, so the CRC32 is a collision
+		return nil, false
+	}
+	return game, ok
+}
+
+// region returns the first parenthesized part of a No-Intro name
+func region(name string) string {
+	start := strings.Index(name, "(")
+	end := strings.Index(name, ")")
+	if start < 0 || end < start {
+		return ""
+	}
+	return name[start+1 : end]
+}
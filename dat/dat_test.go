@@ -0,0 +1,43 @@
+package dat
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDAT(t *testing.T) {
+	good, bad, unknown := []byte("good rom"), []byte("bad rom"), []byte("unknown rom")
+	goodSHA1 := sha1.Sum(good)
+
+	d := New()
+	require.NoError(t, d.Load(strings.NewReader(fmt.Sprintf(`<?xml version="1.0"?>
+<datafile>
+	<header><name>Nintendo - Game Boy</name></header>
+	<game name="Good Game (USA, Europe) (Rev 1)">
+		<rom name="Good Game (USA, Europe) (Rev 1).gb" size="8" crc="%08x" sha1="%s" status="verified"/>
+	</game>
+	<game name="Bad Game (Japan)">
+		<rom name="Bad Game (Japan).gb" size="7" crc="%08X" status="baddump"/>
+	</game>
+</datafile>`, crc32.ChecksumIEEE(good), hex.EncodeToString(goodSHA1[:]), crc32.ChecksumIEEE(bad)))))
+
+	game, ok := d.Lookup(good)
+	require.True(t, ok)
+	require.Equal(t, "Good Game (USA, Europe) (Rev 1)", game.Name)
+	require.Equal(t, "USA, Europe", game.Region)
+	require.True(t, game.Verified())
+
+	game, ok = d.Lookup(bad)
+	require.True(t, ok)
+	require.Equal(t, "Japan", game.Region)
+	require.True(t, game.BadDump())
+
+	_, ok = d.Lookup(unknown)
+	require.False(t, ok)
+}
@@ -0,0 +1,148 @@
+package emulator
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoROM is returned when an archive does not contain a ROM
+var ErrNoROM = errors.New("no rom found in archive")
+
+// maxROMSize is the largest ROM that is extracted from an archive, the largest Game Boy cartridges are 8 MiB
+const maxROMSize = 8 << 20
+
+// romExtensions are the file extensions of ROMs inside archives
+var romExtensions = []string{".gb", ".gbc", ".sgb"}
+
+// ROMFile is a ROM read from disk, either directly or from inside a zip or gzip archive
+type ROMFile struct {
+	// Path is the path of the file that was opened, for ROMs in archives this is the archive
+	Path string
+	// Name is the file name of the ROM, for ROMs in archives this is the name of the entry
+	Name string
+	// Data is the contents of the ROM
+	Data []byte
+}
+
+// OpenROM reads a ROM from path.  Zip and gzip archives are extracted, a zip archive uses its first ROM unless an
+// entry is selected with archive.zip#name.gb.
+func OpenROM(p string) (*ROMFile, error) {
+	entry := ""
+	if _, err := os.Stat(p); err != nil {
+		if i := strings.LastIndex(p, "#"); i >= 0 {
+			p, entry = p[:i], p[i+1:]
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".zip":
+		return openZip(p, entry)
+	case ".gz":
+		return openGzip(p)
+	}
+
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return &ROMFile{Path: p, Name: filepath.Base(p), Data: data}, nil
+}
+
+// BasePath returns the path that files belonging to the ROM, such as saves, patches and cheats, are named after.  It
+// is the directory of the file that was opened joined with the ROM name without its extension, so a save for
+// games/tetris.zip#Tetris (World).gb is games/Tetris (World).sav.
+func (r *ROMFile) BasePath() string {
+	return filepath.Join(filepath.Dir(r.Path), strings.TrimSuffix(r.Name, path.Ext(r.Name)))
+}
+
+func openZip(p, entry string) (*ROMFile, error) {
+	z, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if entry != "" && f.Name != entry {
+			continue
+		}
+		if entry == "" && !isROM(f.Name) {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		data, err := readROM(r, f.Name)
+		if err != nil {
+			return nil, err
+		}
+		return &ROMFile{Path: p, Name: path.Base(f.Name), Data: data}, nil
+	}
+
+	if entry != "" {
+		return nil, fmt.Errorf("%w: %s does not contain %s", ErrNoROM, p, entry)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoROM, p)
+}
+
+func openGzip(p string) (*ROMFile, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := readROM(r, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// The original name is optional in gzip, fall back to the name of the archive without .gz
+	name := filepath.Base(r.Name)
+	if r.Name == "" {
+		name = strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+	}
+	return &ROMFile{Path: p, Name: name, Data: data}, nil
+}
+
+// readROM reads a ROM from an archive, it stops at maxROMSize so that a corrupt or malicious archive cannot use up all
+// memory
+func readROM(r io.Reader, name string) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxROMSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxROMSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxROMSize)
+	}
+	return data, nil
+}
+
+// isROM reports if the name has the extension of a ROM
+func isROM(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range romExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package emulator
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenROM(t *testing.T) {
+	dir := t.TempDir()
+
+	z, err := os.Create(filepath.Join(dir, "games.zip"))
+	require.NoError(t, err)
+	zw := zip.NewWriter(z)
+	for _, entry := range []struct{ name, contents string }{
+		{"readme.txt", "hello"},
+		{"Tetris (World).gb", "tetris"},
+		{"b.gbc", "b"},
+		{"large.gb", ""},
+	} {
+		w, err := zw.Create(entry.name)
+		require.NoError(t, err)
+		w.Write([]byte(entry.contents))
+		if entry.name == "large.gb" {
+			w.Write(make([]byte, maxROMSize+1))
+		}
+	}
+	require.NoError(t, zw.Close())
+	require.NoError(t, z.Close())
+
+	g, err := os.Create(filepath.Join(dir, "zelda.gb.gz"))
+	require.NoError(t, err)
+	gw := gzip.NewWriter(g)
+	gw.Write([]byte("zelda"))
+	require.NoError(t, gw.Close())
+	require.NoError(t, g.Close())
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Zip",
+			test: func(t *testing.T) {
+				rom, err := OpenROM(filepath.Join(dir, "games.zip"))
+				require.NoError(t, err)
+				// The first ROM is picked, entries that are not ROMs are skipped
+				require.Equal(t, "tetris", string(rom.Data))
+				require.Equal(t, "Tetris (World).gb", rom.Name)
+			},
+		},
+		{
+			name: "ZipSelector",
+			test: func(t *testing.T) {
+				rom, err := OpenROM(filepath.Join(dir, "games.zip#Tetris (World).gb"))
+				require.NoError(t, err)
+				require.Equal(t, "tetris", string(rom.Data))
+				require.Equal(t, filepath.Join(dir, "Tetris (World)"), rom.BasePath())
+
+				_, err = OpenROM(filepath.Join(dir, "games.zip#missing.gb"))
+				require.ErrorIs(t, err, ErrNoROM)
+
+				rom, err = OpenROM(filepath.Join(dir, "games.zip#b.gbc"))
+				require.NoError(t, err)
+				require.Equal(t, "b", string(rom.Data))
+			},
+		},
+		{
+			name: "ZipTooLarge",
+			test: func(t *testing.T) {
+				_, err := OpenROM(filepath.Join(dir, "games.zip#large.gb"))
+				require.EqualError(t, err, "large.gb is larger than 8388608 bytes")
+			},
+		},
+		{
+			name: "Gzip",
+			test: func(t *testing.T) {
+				rom, err := OpenROM(filepath.Join(dir, "zelda.gb.gz"))
+				require.NoError(t, err)
+				require.Equal(t, "zelda", string(rom.Data))
+				require.Equal(t, filepath.Join(dir, "zelda"), rom.BasePath())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -0,0 +1,152 @@
+package emulator
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/borgstrom/ebgb/cpu"
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// bootROM returns the boot ROM to run for the selected model.  A nil boot ROM means the boot sequence is skipped and
+// the post-boot state is set up directly.
+func (e *Emulator) bootROM() (mmu.BootROM, error) {
+	if e.options.SkipBootROM {
+		return nil, nil
+	}
+
+	path := e.options.BootROM
+	if path == "" {
+		path = e.options.BootROMs[e.model]
+	}
+	if path == "" {
+		// The DMG boot ROM is built in, the other models fall back to skipping the boot ROM
+		if e.model == DMG {
+			return mmu.DMGBootROM, nil
+		}
+		return nil, nil
+	}
+
+	boot, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	size := mmu.BootROMSize
+	if e.model.IsCGB() {
+		size = mmu.CGBBootROMSize
+	}
+	if len(boot) != size {
+		return nil, fmt.Errorf("boot ROM %s is %d bytes, the %s boot ROM must be %d bytes", path, len(boot), e.model, size)
+	}
+
+	return mmu.BootROM(boot), nil
+}
+
+// postBootRegisters returns the CPU registers as the boot ROM of the model leaves them
+// See: https://gbdev.io/pandocs/Power_Up_Sequence.html#cpu-registers
+func postBootRegisters(model Model, header CartridgeHeader) cpu.Registers {
+	r := cpu.Registers{SP: 0xfffe, PC: 0x0100}
+
+	// The DMG and MGB boot ROMs leave the half carry and carry flags set unless the header checksum is 0
+	flags := uint16(0x80)
+	if header.HeaderChecksum != 0 {
+		flags = 0xb0
+	}
+
+	switch model {
+	case DMG0:
+		r.AF, r.BC, r.DE, r.HL = 0x0100, 0xff13, 0x00c1, 0x8403
+	case DMG:
+		r.AF, r.BC, r.DE, r.HL = 0x0100|flags, 0x0013, 0x00d8, 0x014d
+	case MGB:
+		r.AF, r.BC, r.DE, r.HL = 0xff00|flags, 0x0013, 0x00d8, 0x014d
+	case SGB:
+		r.AF, r.BC, r.DE, r.HL = 0x0100, 0x0014, 0x0000, 0xc060
+	case CGB, AGB:
+		if header.CGB()&0x80 != 0 {
+			r.AF, r.BC, r.DE, r.HL = 0x1180, 0x0000, 0xff56, 0x000d
+		} else {
+			r.AF, r.BC, r.DE, r.HL = 0x1180, 0x0000, 0x0008, 0x007c
+		}
+		if model == AGB {
+			// The AGB boot ROM ends with an extra INC B
+			r.AF, r.BC = 0x1100, r.BC+0x0100
+		}
+	}
+
+	return r
+}
+
+// ioRegister is an I/O register and the value it is written with
+type ioRegister struct {
+	a uint16
+	v uint8
+}
+
+// postBootIO returns the I/O registers as the boot ROM of the model leaves them, in the order they must be written.
+// DIV, LY and DMA are not included since writing them does not set their value.
+// See: https://gbdev.io/pandocs/Power_Up_Sequence.html#hardware-registers
+func postBootIO(model Model) []ioRegister {
+	sc, nr52, stat := uint8(0x7e), uint8(0xf1), uint8(0x85)
+	switch model {
+	case SGB:
+		nr52 = 0xf0
+	case CGB, AGB:
+		sc = 0x7f
+	}
+
+	registers := []ioRegister{
+		{0xff00, 0xcf}, // P1
+		{0xff01, 0x00}, // SB
+		{0xff02, sc},   // SC
+		{0xff05, 0x00}, // TIMA
+		{0xff06, 0x00}, // TMA
+		{0xff07, 0xf8}, // TAC
+		{0xff0f, 0xe1}, // IF
+
+		// The APU must be powered on before the other sound registers are written
+		{0xff26, nr52}, // NR52
+		{0xff10, 0x80}, // NR10
+		{0xff11, 0xbf}, // NR11
+		{0xff12, 0xf3}, // NR12
+		{0xff13, 0xff}, // NR13
+		{0xff14, 0xbf}, // NR14
+		{0xff16, 0x3f}, // NR21
+		{0xff17, 0x00}, // NR22
+		{0xff18, 0xff}, // NR23
+		{0xff19, 0xbf}, // NR24
+		{0xff1a, 0x7f}, // NR30
+		{0xff1b, 0xff}, // NR31
+		{0xff1c, 0x9f}, // NR32
+		{0xff1d, 0xff}, // NR33
+		{0xff1e, 0xbf}, // NR34
+		{0xff20, 0xff}, // NR41
+		{0xff21, 0x00}, // NR42
+		{0xff22, 0x00}, // NR43
+		{0xff23, 0xbf}, // NR44
+		{0xff24, 0x77}, // NR50
+		{0xff25, 0xf3}, // NR51
+
+		{0xff41, stat}, // STAT
+		{0xff42, 0x00}, // SCY
+		{0xff43, 0x00}, // SCX
+		{0xff45, 0x00}, // LYC
+		{0xff47, 0xfc}, // BGP
+		{0xff4a, 0x00}, // WY
+		{0xff4b, 0x00}, // WX
+		{0xff40, 0x91}, // LCDC
+	}
+
+	if model.IsCGB() {
+		registers = append(registers,
+			ioRegister{0xff4d, 0x7e}, // KEY1
+			ioRegister{0xff4f, 0xfe}, // VBK
+			ioRegister{0xff56, 0x3e}, // RP
+			ioRegister{0xff70, 0xf8}, // SVBK
+		)
+	}
+
+	// IE is written last, it is outside of the I/O register range but is part of the post-boot state
+	return append(registers, ioRegister{0xffff, 0x00})
+}
@@ -0,0 +1,205 @@
+package emulator
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/cpu"
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+func TestBootROM(t *testing.T) {
+	dir := t.TempDir()
+
+	// bootFile writes a boot ROM of the given size and returns its path
+	bootFile := func(t *testing.T, name string, size int) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, size), 0644))
+		return path
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Skip",
+			test: func(t *testing.T) {
+				e := &Emulator{model: DMG, options: Options{SkipBootROM: true, BootROM: "missing.bin"}}
+				boot, err := e.bootROM()
+				require.NoError(t, err)
+				require.Nil(t, boot)
+			},
+		},
+		{
+			name: "BuiltIn",
+			test: func(t *testing.T) {
+				e := &Emulator{model: DMG}
+				boot, err := e.bootROM()
+				require.NoError(t, err)
+				require.Equal(t, mmu.DMGBootROM, boot)
+			},
+		},
+		{
+			name: "Fallback",
+			test: func(t *testing.T) {
+				// Only the DMG boot ROM is built in, the other models skip the boot ROM when none is provided
+				for _, model := range []Model{DMG0, MGB, SGB, CGB, AGB} {
+					e := &Emulator{model: model}
+					boot, err := e.bootROM()
+					require.NoError(t, err, model.String())
+					require.Nil(t, boot, model.String())
+				}
+			},
+		},
+		{
+			name: "PerModel",
+			test: func(t *testing.T) {
+				dmg := bootFile(t, "dmg.bin", mmu.BootROMSize)
+				cgb := bootFile(t, "cgb.bin", mmu.CGBBootROMSize)
+				boots := map[Model]string{DMG: dmg, CGB: cgb}
+
+				e := &Emulator{model: DMG, options: Options{BootROMs: boots}}
+				boot, err := e.bootROM()
+				require.NoError(t, err)
+				require.Len(t, boot, mmu.BootROMSize)
+
+				e = &Emulator{model: CGB, options: Options{BootROMs: boots}}
+				boot, err = e.bootROM()
+				require.NoError(t, err)
+				require.Len(t, boot, mmu.CGBBootROMSize)
+
+				// BootROM takes precedence over BootROMs
+				e = &Emulator{model: CGB, options: Options{BootROM: "missing.bin", BootROMs: boots}}
+				_, err = e.bootROM()
+				require.Error(t, err)
+			},
+		},
+		{
+			name: "WrongSize",
+			test: func(t *testing.T) {
+				dmg := bootFile(t, "short.bin", mmu.BootROMSize-1)
+				cgb := bootFile(t, "dmg-on-cgb.bin", mmu.BootROMSize)
+
+				e := &Emulator{model: DMG, options: Options{BootROM: dmg}}
+				_, err := e.bootROM()
+				require.EqualError(t, err, "boot ROM "+dmg+" is 255 bytes, the dmg boot ROM must be 256 bytes")
+
+				e = &Emulator{model: CGB, options: Options{BootROM: cgb}}
+				_, err = e.bootROM()
+				require.EqualError(t, err, "boot ROM "+cgb+" is 256 bytes, the cgb boot ROM must be 2304 bytes")
+			},
+		},
+		{
+			name: "New",
+			test: func(t *testing.T) {
+				// The boot ROM is mapped unless it is skipped or there is none for the model
+				e, err := New(bytes.NewReader(testROM()), Options{Model: DMG})
+				require.NoError(t, err)
+				require.True(t, e.mmu.BootROMEnabled())
+
+				e, err = New(bytes.NewReader(testROM()), Options{Model: DMG, SkipBootROM: true})
+				require.NoError(t, err)
+				require.False(t, e.mmu.BootROMEnabled())
+
+				e, err = New(bytes.NewReader(testROM()), Options{Model: CGB})
+				require.NoError(t, err)
+				require.False(t, e.mmu.BootROMEnabled())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
+
+func TestPostBoot(t *testing.T) {
+	// header returns a header with the CGB flag and header checksum
+	header := func(cgb, checksum uint8) CartridgeHeader {
+		h := CartridgeHeader{HeaderChecksum: checksum}
+		h.Title[15] = cgb
+		return h
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Registers",
+			test: func(t *testing.T) {
+				for _, r := range []struct {
+					model  Model
+					header CartridgeHeader
+					want   cpu.Registers
+				}{
+					{DMG0, header(0x00, 0x4d), cpu.Registers{AF: 0x0100, BC: 0xff13, DE: 0x00c1, HL: 0x8403}},
+					{DMG, header(0x00, 0x4d), cpu.Registers{AF: 0x01b0, BC: 0x0013, DE: 0x00d8, HL: 0x014d}},
+					{DMG, header(0x00, 0x00), cpu.Registers{AF: 0x0180, BC: 0x0013, DE: 0x00d8, HL: 0x014d}},
+					{MGB, header(0x00, 0x4d), cpu.Registers{AF: 0xffb0, BC: 0x0013, DE: 0x00d8, HL: 0x014d}},
+					{SGB, header(0x00, 0x4d), cpu.Registers{AF: 0x0100, BC: 0x0014, DE: 0x0000, HL: 0xc060}},
+					{CGB, header(0x80, 0x4d), cpu.Registers{AF: 0x1180, BC: 0x0000, DE: 0xff56, HL: 0x000d}},
+					{CGB, header(0x00, 0x4d), cpu.Registers{AF: 0x1180, BC: 0x0000, DE: 0x0008, HL: 0x007c}},
+					{AGB, header(0xc0, 0x4d), cpu.Registers{AF: 0x1100, BC: 0x0100, DE: 0xff56, HL: 0x000d}},
+				} {
+					r.want.SP, r.want.PC = 0xfffe, 0x0100
+					require.Equal(t, r.want, postBootRegisters(r.model, r.header), r.model.String())
+				}
+			},
+		},
+		{
+			name: "IO",
+			test: func(t *testing.T) {
+				// values returns the post-boot I/O registers of a model by address
+				values := func(model Model) map[uint16]uint8 {
+					v := map[uint16]uint8{}
+					for _, r := range postBootIO(model) {
+						v[r.a] = r.v
+					}
+					return v
+				}
+
+				dmg, sgb, cgb := values(DMG), values(SGB), values(CGB)
+				require.EqualValues(t, 0x7e, dmg[0xff02])
+				require.EqualValues(t, 0xf1, dmg[0xff26])
+				require.EqualValues(t, 0xf0, sgb[0xff26])
+				require.EqualValues(t, 0x7f, cgb[0xff02])
+				require.Equal(t, cgb, values(AGB))
+
+				// The CGB registers are only written on the CGB
+				require.NotContains(t, dmg, uint16(0xff4d))
+				require.EqualValues(t, 0xf8, cgb[0xff70])
+
+				// The APU is powered on before its registers are written and IE is written last
+				io := postBootIO(DMG)
+				require.EqualValues(t, 0xff26, io[7].a)
+				require.EqualValues(t, 0xffff, io[len(io)-1].a)
+			},
+		},
+		{
+			name: "Skip",
+			test: func(t *testing.T) {
+				// Skipping the boot ROM leaves the registers in their post-boot state
+				e, err := New(bytes.NewReader(testROM()), Options{Model: DMG, SkipBootROM: true})
+				require.NoError(t, err)
+				require.EqualValues(t, 0x91, e.Read(0xff40))
+				require.EqualValues(t, 0xfc, e.Read(0xff47))
+				require.EqualValues(t, 0x7e, e.Read(0xff02))
+
+				e, err = New(bytes.NewReader(testROM()), Options{Model: CGB, SkipBootROM: true})
+				require.NoError(t, err)
+				require.EqualValues(t, 0x7f, e.Read(0xff02))
+				require.EqualValues(t, 0x7e, e.Read(0xff4d))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -1,42 +1,88 @@
 package emulator
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+
+	"github.com/borgstrom/ebgb/patch"
 )
 
 type Cartridge struct {
 	Size   int
 	ROM    []uint8
 	Header CartridgeHeader
+
+	// Warnings holds the problems found while validating a cartridge loaded with the Lenient policy
+	Warnings []error
 }
 
-func Load(file io.ReadSeeker) (*Cartridge, error) {
+// headerEnd is the first address after the cartridge header, a ROM must be at least this large
+const headerEnd = 0x0150
+
+// Load reads a cartridge ROM.  If p is not nil it is applied as an IPS, BPS or UPS patch before the header is parsed.
+// The header is then validated, problems are handled according to policy.
+func Load(file io.ReadSeeker, p []byte, policy Policy) (*Cartridge, error) {
 	rom, err := ioutil.ReadAll(file)
 	if err != nil {
 		return nil, err
 	}
 
+	if p != nil {
+		rom, err = patch.Apply(rom, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(rom) < headerEnd {
+		return nil, fmt.Errorf("%w: %d bytes is too small to hold a header", ErrTruncated, len(rom))
+	}
+
 	header := CartridgeHeader{}
-	file.Seek(0x0100, io.SeekStart)
-	err = binary.Read(file, binary.LittleEndian, &header)
+	r := bytes.NewReader(rom)
+	r.Seek(0x0100, io.SeekStart)
+	err = binary.Read(r, binary.LittleEndian, &header)
 	if err != nil {
 		return nil, err
 	}
 
+	problems := validate(rom, header)
+	if policy == Strict && len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
 	return &Cartridge{
-		ROM:    rom,
-		Size:   len(rom),
-		Header: header,
+		ROM:      rom,
+		Size:     len(rom),
+		Header:   header,
+		Warnings: problems,
 	}, nil
 }
 
+// FindPatch returns the path of a patch next to the ROM, or an empty string if there is none.  A base is the path of
+// the ROM without its extension, as returned by ROMFile.BasePath, the bases are searched in order.
+func FindPatch(bases ...string) string {
+	for _, base := range bases {
+		for _, ext := range patch.Extensions {
+			if _, err := os.Stat(base + ext); err == nil {
+				return base + ext
+			}
+		}
+	}
+	return ""
+}
+
+// CartridgeHeader is the header at 0x0100 - 0x014f of every cartridge
+// See: https://gbdev.io/pandocs/The_Cartridge_Header.html
 type CartridgeHeader struct {
-	EntryPoint      [4]uint8
-	Logo            [48]uint8
+	EntryPoint [4]uint8
+	Logo       [48]uint8
+	// Title is 16 bytes on early cartridges, later cartridges use the last byte for the CGB flag
 	Title           [16]uint8
-	CGB             uint8
 	NewLicenseeCode [2]uint8
 	SGB             uint8
 	Type            uint8
@@ -48,3 +94,23 @@ type CartridgeHeader struct {
 	HeaderChecksum  uint8
 	GlobalChecksum  [2]uint8
 }
+
+// CGB returns the CGB flag, bit 7 is set for cartridges that support the CGB
+func (h CartridgeHeader) CGB() uint8 {
+	return h.Title[15]
+}
+
+// ROMBytes returns the size of the ROM in bytes as given by the header, it returns false if the size is not known
+func (h CartridgeHeader) ROMBytes() (int, bool) {
+	switch {
+	case h.ROMSize <= 0x08:
+		return 0x8000 << h.ROMSize, true
+	case h.ROMSize == 0x52:
+		return 72 * 0x4000, true
+	case h.ROMSize == 0x53:
+		return 80 * 0x4000, true
+	case h.ROMSize == 0x54:
+		return 96 * 0x4000, true
+	}
+	return 0, false
+}
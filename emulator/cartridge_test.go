@@ -0,0 +1,81 @@
+package emulator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testROM returns a 32KiB ROM with a valid header
+func testROM() []uint8 {
+	rom := make([]uint8, 0x8000)
+	copy(rom[0x0104:], nintendoLogo)
+	copy(rom[0x0134:], "TESTROM")
+	rom[0x014d] = HeaderChecksum(rom)
+	sum := GlobalChecksum(rom)
+	rom[0x014e], rom[0x014f] = uint8(sum>>8), uint8(sum)
+	return rom
+}
+
+func TestLoad(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Valid",
+			test: func(t *testing.T) {
+				c, err := Load(bytes.NewReader(testROM()), nil, Strict)
+				require.NoError(t, err)
+				require.Empty(t, c.Warnings)
+				require.Equal(t, "TESTROM", string(c.Header.Title[:7]))
+			},
+		},
+		{
+			name: "TooSmallForHeader",
+			test: func(t *testing.T) {
+				_, err := Load(bytes.NewReader(make([]uint8, 0x100)), nil, Lenient)
+				require.ErrorIs(t, err, ErrTruncated)
+			},
+		},
+		{
+			name: "Truncated",
+			test: func(t *testing.T) {
+				rom := testROM()[:0x4000]
+				_, err := Load(bytes.NewReader(rom), nil, Strict)
+				require.ErrorIs(t, err, ErrTruncated)
+
+				c, err := Load(bytes.NewReader(rom), nil, Lenient)
+				require.NoError(t, err)
+				require.Len(t, c.Warnings, 1)
+				require.ErrorIs(t, c.Warnings[0], ErrTruncated)
+			},
+		},
+		{
+			name: "HeaderChecksum",
+			test: func(t *testing.T) {
+				rom := testROM()
+				rom[0x0134] = 'X'
+				_, err := Load(bytes.NewReader(rom), nil, Strict)
+				require.ErrorIs(t, err, ErrHeaderChecksum)
+				require.ErrorIs(t, err, ErrGlobalChecksum)
+				require.NotErrorIs(t, err, ErrLogo)
+			},
+		},
+		{
+			name: "Logo",
+			test: func(t *testing.T) {
+				rom := testROM()
+				rom[0x0104] = 0x00
+				c, err := Load(bytes.NewReader(rom), nil, Lenient)
+				require.NoError(t, err)
+				require.ErrorIs(t, c.Warnings[0], ErrLogo)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
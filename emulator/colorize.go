@@ -0,0 +1,314 @@
+package emulator
+
+import (
+	"fmt"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// PaletteCombo is a combination of buttons held while the CGB boot ROM shows the logo, which overrides the colors it
+// picks for a DMG cartridge
+type PaletteCombo int
+
+const (
+	// NoCombo keeps the colors selected from the cartridge header
+	NoCombo PaletteCombo = iota
+	ComboUp
+	ComboUpA
+	ComboUpB
+	ComboLeft
+	ComboLeftA
+	ComboLeftB
+	ComboDown
+	ComboDownA
+	ComboDownB
+	ComboRight
+	ComboRightA
+	ComboRightB
+)
+
+var comboNames = map[PaletteCombo]string{
+	NoCombo:     "none",
+	ComboUp:     "up",
+	ComboUpA:    "up+a",
+	ComboUpB:    "up+b",
+	ComboLeft:   "left",
+	ComboLeftA:  "left+a",
+	ComboLeftB:  "left+b",
+	ComboDown:   "down",
+	ComboDownA:  "down+a",
+	ComboDownB:  "down+b",
+	ComboRight:  "right",
+	ComboRightA: "right+a",
+	ComboRightB: "right+b",
+}
+
+// comboPalettes maps each button combination to its entry in paletteCombinations
+var comboPalettes = map[PaletteCombo]int{
+	ComboUp:     5,
+	ComboUpA:    43,
+	ComboUpB:    28,
+	ComboLeft:   48,
+	ComboLeftA:  40,
+	ComboLeftB:  7,
+	ComboDown:   8,
+	ComboDownA:  3,
+	ComboDownB:  49,
+	ComboRight:  1,
+	ComboRightA: 0,
+	ComboRightB: 6,
+}
+
+func (c PaletteCombo) String() string {
+	if name, ok := comboNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("PaletteCombo(%d)", int(c))
+}
+
+// ParsePaletteCombo returns the PaletteCombo for a name such as "up+a" or "none"
+func ParsePaletteCombo(name string) (PaletteCombo, error) {
+	for combo, n := range comboNames {
+		if n == name {
+			return combo, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown palette combo %q", name)
+}
+
+// colorize returns the colors the CGB boot ROM gives the background and both sprite palettes of a DMG cartridge.
+// Cartridges from Nintendo are looked up by a checksum of their title, other cartridges and unknown titles get the
+// same colors as Right + A.
+func colorize(h CartridgeHeader, combo PaletteCombo) (bg, obj0, obj1 [4]gpu.Color) {
+	index, ok := comboPalettes[combo]
+	if !ok {
+		index = titlePalette(h)
+	}
+
+	c := paletteCombinations[index]
+	return compatibilityColors(c[0]), compatibilityColors(c[1]), compatibilityColors(c[2])
+}
+
+// titlePalette returns the entry in paletteCombinations for a cartridge, based on its licensee and title
+func titlePalette(h CartridgeHeader) int {
+	nintendo := h.OldLicenseeCode == 0x01 || (h.OldLicenseeCode == 0x33 && string(h.NewLicenseeCode[:]) == "01")
+	if !nintendo {
+		return 0
+	}
+
+	var sum uint8
+	for _, c := range h.Title {
+		sum += c
+	}
+	for _, t := range titleChecksums {
+		if t.checksum == sum && (t.letter == 0 || t.letter == h.Title[3]) {
+			return t.palette
+		}
+	}
+	return 0
+}
+
+// compatibilityColors returns the 4 colors starting at offset in compatibilityPalettes, a few palette combinations
+// start in the middle of a palette
+func compatibilityColors(offset int) [4]gpu.Color {
+	var colors [4]gpu.Color
+	for i := range colors {
+		colors[i] = compatibilityPalettes[(offset+i)/4][(offset+i)%4]
+	}
+	return colors
+}
+
+// titleChecksum is an entry of the title checksum table of the CGB boot ROM.  Some checksums are shared by several
+// titles, those are told apart by the 4th letter of the title.
+type titleChecksum struct {
+	checksum uint8
+	letter   uint8
+	palette  int
+}
+
+// titleChecksums holds the titles of Nintendo cartridges that the CGB boot ROM has colors for, the first match wins
+var titleChecksums = []titleChecksum{
+	{0x88, 0, 4},  // ALLEY WAY
+	{0x16, 0, 5},  // YAKUMAN
+	{0x36, 0, 35}, // BASEBALL, (Game and Watch 2)
+	{0xd1, 0, 34}, // TENNIS
+	{0xdb, 0, 3},  // TETRIS
+	{0xf2, 0, 31}, // QIX
+	{0x3c, 0, 15}, // DR.MARIO
+	{0x8c, 0, 10}, // RADARMISSION
+	{0x92, 0, 5},  // F1RACE
+	{0x3d, 0, 19}, // YOSSY NO TAMAGO
+	{0x5c, 0, 36},
+	{0x58, 0, 7},  // X
+	{0xc9, 0, 37}, // MARIOLAND2
+	{0x3e, 0, 30}, // YOSSY NO COOKIE
+	{0x70, 0, 44}, // ZELDA
+	{0x1d, 0, 21},
+	{0x59, 0, 32},
+	{0x69, 0, 31}, // TETRIS FLASH
+	{0x19, 0, 20}, // DONKEY KONG
+	{0x35, 0, 5},  // MARIO'S PICROSS
+	{0xa8, 0, 33},
+	{0x14, 0, 13}, // POKEMON RED, (GAMEBOYCAMERA G)
+	{0xaa, 0, 14}, // POKEMON GREEN
+	{0x75, 0, 5},  // PICROSS 2
+	{0x95, 0, 29}, // YOSSY NO PANEPON
+	{0x99, 0, 5},  // KIRAKIRA KIDS
+	{0x34, 0, 18}, // GAMEBOY GALLERY
+	{0x6f, 0, 9},  // POCKETCAMERA
+	{0x15, 0, 3},
+	{0xff, 0, 2},  // BALLOON KID
+	{0x97, 0, 26}, // KINGOFTHEZOO
+	{0x4b, 0, 25}, // DMG FOOTBALL
+	{0x90, 0, 25}, // WORLD CUP
+	{0x17, 0, 41}, // OTHELLO
+	{0x10, 0, 42}, // SUPER RC PRO-AM
+	{0x39, 0, 26}, // DYNABLASTER
+	{0xf7, 0, 45}, // BOY AND BLOB GB2
+	{0xf6, 0, 42}, // MEGAMAN
+	{0xa2, 0, 45}, // STAR WARS-NOA
+	{0x49, 0, 36},
+	{0x4e, 0, 38}, // WAVERACE
+	{0x43, 0, 26},
+	{0x68, 0, 42}, // LOLO2
+	{0xe0, 0, 30}, // YOSHI'S COOKIE
+	{0x8b, 0, 41}, // MYSTIC QUEST
+	{0xf0, 0, 34},
+	{0xce, 0, 34}, // TOPRANKINGTENNIS
+	{0x0c, 0, 5},  // MANSELL
+	{0x29, 0, 42}, // MEGAMAN3
+	{0xe8, 0, 6},  // SPACE INVADERS
+	{0xb7, 0, 5},  // GAME&WATCH
+	{0x86, 0, 33}, // DONKEYKONGLAND95
+	{0x9a, 0, 25}, // ASTEROIDS/MISCMD
+	{0x52, 0, 42}, // STREET FIGHTER 2
+	{0x01, 0, 42}, // DEFENDER/JOUST
+	{0x9d, 0, 40}, // KILLERINSTINCT95
+	{0x71, 0, 2},  // TETRIS BLAST
+	{0x9c, 0, 16}, // PINOCCHIO
+	{0xbd, 0, 25},
+	{0x5d, 0, 42}, // BA.TOSHINDEN
+	{0x6d, 0, 42}, // NETTOU KOF 95
+	{0x67, 0, 5},
+	{0x3f, 0, 0},    // TETRIS PLUS
+	{0x6b, 0, 39},   // DONKEYKONGLAND 3
+	{0xb3, 'B', 36}, // ???[B]????????
+	{0x46, 'E', 22}, // SUP[E]R MARIOLAND
+	{0x28, 'F', 25}, // GOL[F]
+	{0xa5, 'A', 6},  // SOL[A]RSTRIKER
+	{0xc6, 'A', 32}, // GBW[A]RS
+	{0xd3, 'R', 12}, // KAE[R]UNOTAMENI
+	{0x27, 'B', 36}, // ???[B]????????
+	{0x61, 'E', 11}, // POK[E]MON BLUE
+	{0x18, 'K', 39}, // DON[K]EYKONGLAND
+	{0x66, 'E', 18}, // GAM[E]BOY GALLERY2
+	{0x6a, 'K', 39}, // DON[K]EYKONGLAND 2
+	{0xbf, ' ', 24}, // KID[ ]ICARUS
+	{0x0d, 'R', 31}, // TET[R]IS2
+	{0xf4, '-', 50}, // ???[-]????????
+	{0xb3, 'U', 17}, // MOG[U]RANYA
+	{0x46, 'R', 46}, // ???[R]????????
+	{0x28, 'A', 6},  // GAL[A]GA&GALAXIAN
+	{0xa5, 'R', 27}, // BT2[R]AGNAROKWORLD
+	{0xc6, ' ', 0},  // KEN[ ]GRIFFEY JR
+	{0xd3, 'I', 47}, // ???[I]????????
+	{0x27, 'N', 41}, // MAG[N]ETIC SOCCER
+	{0x61, 'A', 41}, // VEG[A]S STAKES
+	{0x18, 'I', 0},  // ???[I]????????
+	{0x66, 'L', 0},  // MIL[L]I/CENTI/PEDE
+	{0x6a, 'I', 19}, // MAR[I]O & YOSHI
+	{0xbf, 'C', 34}, // SOC[C]ER
+	{0x0d, 'E', 23}, // POK[E]BOM
+	{0xf4, ' ', 18}, // G&W[ ]GALLERY
+	{0xb3, 'R', 29}, // TET[R]IS ATTACK
+}
+
+// paletteCombinations are the colors of the background and both sprite palettes, given as the offset of their first
+// color in compatibilityPalettes
+var paletteCombinations = [...][3]int{
+	{116, 16, 16}, // Right + A
+	{72, 72, 72},  // Right
+	{80, 80, 80},
+	{96, 96, 96}, // Down + A
+	{36, 36, 36},
+	{0, 0, 0},       // Up
+	{108, 108, 108}, // Right + B
+	{20, 20, 20},    // Left + B
+	{48, 48, 48},    // Down
+	{104, 104, 104},
+	{32, 64, 32},
+	{112, 16, 112},
+	{8, 16, 8},
+	{16, 12, 16},
+	{116, 16, 116},
+	{112, 112, 16},
+	{8, 8, 68},
+	{32, 64, 64},
+	{28, 16, 16},
+	{72, 16, 16},
+	{80, 16, 16},
+	{36, 76, 76},
+	{44, 15, 15},
+	{8, 68, 68},
+	{8, 16, 16},
+	{12, 16, 16},
+	{0, 112, 112},
+	{0, 12, 12},
+	{4, 0, 0}, // Up + B
+	{72, 72, 88},
+	{80, 80, 88},
+	{96, 96, 88},
+	{32, 64, 88},
+	{52, 68, 16},
+	{56, 111, 0},
+	{60, 111, 16},
+	{36, 76, 91},
+	{40, 64, 112},
+	{112, 16, 92},
+	{8, 68, 88},
+	{8, 16, 0}, // Left + A
+	{12, 16, 112},
+	{0, 112, 12},
+	{16, 12, 112}, // Up + A
+	{16, 84, 112},
+	{0, 12, 112},
+	{112, 100, 12},
+	{32, 0, 112},
+	{112, 16, 12}, // Left
+	{24, 112, 12}, // Down + B
+	{116, 16, 112},
+}
+
+// compatibilityPalettes are the palettes stored in the CGB boot ROM
+var compatibilityPalettes = [...][4]gpu.Color{
+	{0x7fff, 0x32bf, 0x00d0, 0x0000},
+	{0x639f, 0x4279, 0x15b0, 0x04cb},
+	{0x7fff, 0x6e31, 0x454a, 0x0000},
+	{0x7fff, 0x1bef, 0x0200, 0x0000},
+	{0x7fff, 0x421f, 0x1cf2, 0x0000},
+	{0x7fff, 0x5294, 0x294a, 0x0000},
+	{0x7fff, 0x03ff, 0x012f, 0x0000},
+	{0x7fff, 0x03ef, 0x01d6, 0x0000},
+	{0x7fff, 0x42b5, 0x3dc8, 0x0000},
+	{0x7e74, 0x03ff, 0x0180, 0x0000},
+	{0x67ff, 0x77ac, 0x1a13, 0x2d6b},
+	{0x7ed6, 0x4bff, 0x2175, 0x0000},
+	{0x53ff, 0x4a5f, 0x7e52, 0x0000},
+	{0x4fff, 0x7ed2, 0x3a4c, 0x1ce0},
+	{0x03ed, 0x7fff, 0x255f, 0x0000},
+	{0x036a, 0x021f, 0x03ff, 0x7fff},
+	{0x7fff, 0x01df, 0x0112, 0x0000},
+	{0x231f, 0x035f, 0x00f2, 0x0009},
+	{0x7fff, 0x03ea, 0x011f, 0x0000},
+	{0x299f, 0x001a, 0x000c, 0x0000},
+	{0x7fff, 0x027f, 0x001f, 0x0000},
+	{0x7fff, 0x03e0, 0x0206, 0x0120},
+	{0x7fff, 0x7eeb, 0x001f, 0x7c00},
+	{0x7fff, 0x3fff, 0x7e00, 0x001f},
+	{0x7fff, 0x03ff, 0x001f, 0x0000},
+	{0x03ff, 0x001f, 0x000c, 0x0000},
+	{0x7fff, 0x033f, 0x0193, 0x0000},
+	{0x0000, 0x4200, 0x037f, 0x7fff},
+	{0x7fff, 0x7e8c, 0x7c00, 0x0000},
+	{0x7fff, 0x1bef, 0x6180, 0x0000},
+}
@@ -0,0 +1,84 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+func TestColorize(t *testing.T) {
+	white, black := gpu.Color(0x7fff), gpu.Color(0x0000)
+
+	// header returns the header of a cartridge with the title and old licensee code
+	header := func(title string, licensee uint8) CartridgeHeader {
+		h := CartridgeHeader{OldLicenseeCode: licensee}
+		copy(h.Title[:], title)
+		return h
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Title",
+			test: func(t *testing.T) {
+				bg, obj0, obj1 := colorize(header("TETRIS", 0x01), NoCombo)
+				require.Equal(t, [4]gpu.Color{white, 0x03ff, 0x001f, black}, bg)
+				require.Equal(t, bg, obj0)
+				require.Equal(t, bg, obj1)
+			},
+		},
+		{
+			name: "NewLicensee",
+			test: func(t *testing.T) {
+				h := header("TETRIS", 0x33)
+				h.NewLicenseeCode = [2]uint8{'0', '1'}
+				require.Equal(t, titlePalette(header("TETRIS", 0x01)), titlePalette(h))
+			},
+		},
+		{
+			name: "FourthLetter",
+			test: func(t *testing.T) {
+				// Both titles have a checksum of 0x61
+				require.Equal(t, 11, titlePalette(header("POKEMON BLUE", 0x01)))
+				require.Equal(t, 41, titlePalette(header("VEGAS STAKES", 0x01)))
+			},
+		},
+		{
+			name: "NotNintendo",
+			test: func(t *testing.T) {
+				require.Zero(t, titlePalette(header("TETRIS", 0x00)))
+
+				// Unknown cartridges get the colors of Right + A
+				bg, obj0, obj1 := colorize(header("TETRIS", 0x00), NoCombo)
+				rbg, robj0, robj1 := colorize(header("TETRIS", 0x01), ComboRightA)
+				require.Equal(t, [4]gpu.Color{white, 0x1bef, 0x6180, black}, bg)
+				require.Equal(t, [4]gpu.Color{white, 0x421f, 0x1cf2, black}, obj0)
+				require.Equal(t, obj0, obj1)
+				require.Equal(t, rbg, bg)
+				require.Equal(t, robj0, obj0)
+				require.Equal(t, robj1, obj1)
+			},
+		},
+		{
+			name: "Combo",
+			test: func(t *testing.T) {
+				bg, _, _ := colorize(header("TETRIS", 0x01), ComboLeftB)
+				require.Equal(t, [4]gpu.Color{white, 0x5294, 0x294a, black}, bg)
+
+				combo, err := ParsePaletteCombo("left+b")
+				require.NoError(t, err)
+				require.Equal(t, ComboLeftB, combo)
+				_, err = ParsePaletteCombo("select")
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -0,0 +1,56 @@
+package emulator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Config holds the persistent user settings, it is stored as JSON in the user's config directory
+type Config struct {
+	// BootROMs maps a model to the path of its boot ROM, for example {"cgb": "/path/to/cgb_boot.bin"}
+	BootROMs map[Model]string `json:"bootroms"`
+	// DATs are the paths of No-Intro DAT files used to identify ROMs
+	DATs []string `json:"dats"`
+	// Games holds per-game settings, keyed by the No-Intro name of the game
+	Games map[string]GameConfig `json:"games"`
+	// Palette is the name of the palette to start with, either a preset or one of Palettes
+	Palette string `json:"palette"`
+	// Palettes are the paths of palette files that are loaded in addition to the presets
+	Palettes []string `json:"palettes"`
+}
+
+// GameConfig holds the settings for a single game, they are used when the ROM is identified through a DAT file
+type GameConfig struct {
+	// Model overrides the model selected from the cartridge header
+	Model Model `json:"model"`
+	// Cheats are codes that are always applied to the game
+	Cheats []string `json:"cheats"`
+}
+
+// DefaultConfigPath returns the location of the config file, or an empty string if the user has no config directory
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ebgb", "config.json")
+}
+
+// LoadConfig reads the config file at path, a missing file results in an empty Config
+func LoadConfig(path string) (*Config, error) {
+	config := &Config{}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
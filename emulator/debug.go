@@ -0,0 +1,48 @@
+package emulator
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"strings"
+)
+
+// SaveDebugViews writes the debug views of the GPU next to the ROM: the tile sheet, both tile maps, the sprites, the
+// OAM table and the palettes as PNG files, and the decoded OAM entries as a text file.  It returns the paths of the files.
+func (e *Emulator) SaveDebugViews() ([]string, error) {
+	base := e.options.BasePath
+	if base == "" {
+		base = "debug"
+	}
+
+	views := []struct {
+		name  string
+		image image.Image
+	}{
+		{"tiles", e.gpu.TileSheet()},
+		{"map0", e.gpu.TileMap(0)},
+		{"map1", e.gpu.TileMap(1)},
+		{"oam", e.gpu.OAMSheet()},
+		{"oamtable", e.gpu.OAMTable()},
+		{"palettes", e.gpu.PaletteSheet()},
+	}
+
+	var paths []string
+	for _, view := range views {
+		path := fmt.Sprintf("%s-%s.png", base, view.name)
+		if err := SavePNG(path, view.image); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	var table strings.Builder
+	for _, s := range e.gpu.OAM() {
+		fmt.Fprintln(&table, s)
+	}
+	path := base + "-oam.txt"
+	if err := ioutil.WriteFile(path, []byte(table.String()), 0644); err != nil {
+		return paths, err
+	}
+	return append(paths, path), nil
+}
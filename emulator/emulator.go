@@ -1,123 +1,357 @@
 package emulator
 
 import (
-	"context"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"time"
 
-	"github.com/veandco/go-sdl2/sdl"
-
+	"github.com/borgstrom/ebgb/cheats"
 	"github.com/borgstrom/ebgb/cpu"
 	"github.com/borgstrom/ebgb/gpu"
 	"github.com/borgstrom/ebgb/mmu"
 )
 
+// Options control how the Emulator is set up
+type Options struct {
+	// Title names the game in the window title, it defaults to the title from the cartridge header
+	Title string
+
+	// Patch is the path of an IPS, BPS or UPS patch to apply to the ROM
+	Patch string
+	// Policy selects if a cartridge that fails validation is loaded
+	Policy Policy
+
+	// Model is the hardware to emulate, Auto selects it from the cartridge header
+	Model Model
+
+	// BootROM is the path of a boot ROM to run, it takes precedence over BootROMs
+	BootROM string
+	// BootROMs maps each model to the path of its boot ROM.  Models without an entry use the built in DMG boot ROM
+	// for the DMG, and skip the boot ROM otherwise.
+	BootROMs map[Model]string
+	// SkipBootROM starts the cartridge directly, with the CPU and I/O registers set as the boot ROM would leave them
+	SkipBootROM bool
+
+	// RAMFill selects the contents of RAM after a hard reset
+	RAMFill mmu.Fill
+	// RAMSeed seeds the generator used by mmu.FillRandom, when it is 0 a seed is picked and logged so that the run
+	// can be reproduced
+	RAMSeed int64
+
+	// Renderer selects how the GPU draws the screen
+	Renderer gpu.Renderer
+	// Palette holds the colors of the DMG shades, the first of Presets is used if it has no name
+	Palette Palette
+	// Palettes are the palettes NextPalette cycles through, Presets are used if it is empty
+	Palettes []Palette
+	// PaletteCombo overrides the colors a CGB picks for DMG cartridges, as if the buttons were held during boot
+	PaletteCombo PaletteCombo
+	// HiddenLayers are the layers of the screen that aren't drawn, and Overlays the debug overlays drawn over it
+	HiddenLayers gpu.Layers
+	Overlays     gpu.Overlays
+
+	// BasePath is the path files belonging to the ROM are named after, TakeScreenshot saves screenshots next to it
+	BasePath string
+	// ScreenshotScale scales screenshots up by a whole number, they are saved at 160x144 when it is 0 or 1
+	ScreenshotScale int
+}
+
+// ResetKind selects what is reset by Emulator.Reset
+type ResetKind int
+
+const (
+	// HardReset power cycles the Game Boy, RAM is filled according to Options.RAMFill
+	HardReset ResetKind = iota
+	// SoftReset restarts the Game Boy without touching the contents of RAM, VRAM and OAM
+	SoftReset
+)
+
 type Emulator struct {
 	cartridge *Cartridge
+	options   Options
+	model     Model
+	boot      mmu.BootROM
+	palette   Palette
 
 	mmu *mmu.MMU
 	cpu *cpu.CPU
 	gpu *gpu.GPU
 
+	cheats *cheats.Engine
+	joypad *joypad
+	serial *serial
+
+	video VideoSink
+	audio AudioSink
+	input InputSource
+
+	recorder Recorder
+
+	// screenshotCorrection is the color correction applied to screenshots
+	screenshotCorrection gpu.Correction
+
+	// frames counts the completed frames, frameDots the dots since the last one
+	frames    uint64
+	frameDots uint32
+
 	fps           int
 	currentSecond int
 }
 
-func New(file io.ReadSeeker) *Emulator {
-	cartridge, err := Load(file)
+// New loads a cartridge and returns an Emulator that has been powered on.  Validation problems with the cartridge that
+// were ignored because of a Lenient policy are available from Cartridge().Warnings.
+func New(file io.ReadSeeker, options Options) (*Emulator, error) {
+	var p []byte
+	if options.Patch != "" {
+		var err error
+		if p, err = ioutil.ReadFile(options.Patch); err != nil {
+			return nil, fmt.Errorf("failed to read patch: %w", err)
+		}
+	}
+
+	cartridge, err := Load(file, p, options.Policy)
 	if err != nil {
-		log.Fatalf("Failed to load rom: %s", err)
+		return nil, fmt.Errorf("failed to load rom: %w", err)
 	}
 
 	e := &Emulator{
 		cartridge: cartridge,
+		options:   options,
+		model:     options.Model,
+		palette:   options.Palette,
+		cheats:    cheats.New(),
+		joypad:    &joypad{},
+		serial:    &serial{},
+	}
+	if e.model == Auto {
+		e.model = SelectModel(cartridge.Header)
+	}
+	if e.palette.Name == "" {
+		e.palette = Presets[0]
 	}
-	e.Reset()
-	return e
-}
 
-func (e *Emulator) Reset() {
-	e.mmu = mmu.New(e.cartridge.ROM)
-	e.cpu = cpu.New(e.mmu)
-	e.gpu = gpu.New(e.mmu)
+	if e.boot, err = e.bootROM(); err != nil {
+		return nil, fmt.Errorf("failed to load boot rom: %w", err)
+	}
+
+	e.Reset(HardReset)
+	return e, nil
 }
 
-// Run starts the *Emulator, must be run in the main thread to satisfy SDL
-func (e *Emulator) Run(parentCtx context.Context) {
-	ctx, cancel := context.WithCancel(parentCtx)
-	defer cancel()
+// Cartridge returns the loaded cartridge
+func (e *Emulator) Cartridge() *Cartridge {
+	return e.cartridge
+}
 
-	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
-		panic(err)
+// Title returns the title to show in a window, the game's name followed by the name of the emulator
+func (e *Emulator) Title() string {
+	title := e.options.Title
+	if title == "" {
+		title = e.cartridge.Info().Title
+	}
+	if title == "" {
+		return "ebgb"
 	}
-	defer sdl.Quit()
+	return title + " - ebgb"
+}
 
-	window, err := sdl.CreateWindow(
-		"ebgb",
-		sdl.WINDOWPOS_UNDEFINED,
-		sdl.WINDOWPOS_UNDEFINED,
-		int32(width),
-		int32(height),
-		sdl.WINDOW_SHOWN,
-	)
+// GPU returns the GPU, for its debug views
+func (e *Emulator) GPU() *gpu.GPU {
+	return e.gpu
+}
 
-	if err != nil {
-		panic(err)
+// Cheats returns the cheat engine, codes can be added, removed, enabled and disabled while the emulator runs
+func (e *Emulator) Cheats() *cheats.Engine {
+	return e.cheats
+}
+
+// Model returns the hardware model being emulated
+func (e *Emulator) Model() Model {
+	return e.model
+}
+
+// colorMode reports if the PPU runs in color mode, which is the case for CGB cartridges on CGB hardware.  DMG
+// cartridges run in the compatibility mode of the CGB, which uses the DMG registers.
+func (e *Emulator) colorMode() bool {
+	return e.model.IsCGB() && e.cartridge.Header.CGB()&0x80 != 0
+}
+
+// Palette returns the colors used for the DMG shades
+func (e *Emulator) Palette() Palette {
+	return e.palette
+}
+
+// SetPalette changes the colors used for the DMG shades.  On the CGB it replaces the colors picked for a DMG cartridge,
+// cartridges running in CGB mode are not affected.
+func (e *Emulator) SetPalette(p Palette) {
+	e.palette = p
+	e.gpu.SetDMGColors(p.BG, p.OBJ0, p.OBJ1)
+}
+
+// NextPalette switches to the palette after the current one in Options.Palettes
+func (e *Emulator) NextPalette() {
+	palettes := e.options.Palettes
+	if len(palettes) == 0 {
+		palettes = Presets
 	}
-	defer window.Destroy()
 
-	surface, err := window.GetSurface()
-	if err != nil {
-		panic(err)
+	next := 0
+	for i, p := range palettes {
+		if p.Name == e.palette.Name {
+			next = (i + 1) % len(palettes)
+		}
 	}
-	surface.FillRect(nil, g0)
+	e.SetPalette(palettes[next])
+	log.Printf("Palette: %s", palettes[next].Name)
+}
 
-	//rect := sdl.Rect{0, 0, 200, 200}
-	//surface.FillRect(&rect, g0)
-	window.UpdateSurface()
+// ToggleLayer hides a layer of the screen if it is shown and shows it otherwise
+func (e *Emulator) ToggleLayer(l gpu.Layers) {
+	e.options.HiddenLayers ^= l
+	e.gpu.SetHiddenLayers(e.options.HiddenLayers)
+	log.Printf("Hidden layers: %s", e.options.HiddenLayers)
+}
 
-	//lastTick := sdl.GetTicks()
+// ToggleOverlay turns a debug overlay on or off
+func (e *Emulator) ToggleOverlay(o gpu.Overlays) {
+	e.options.Overlays ^= o
+	e.gpu.SetOverlays(e.options.Overlays)
+	log.Printf("Overlays: %s", e.options.Overlays)
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			// no-op
-		}
+// Reset restarts the Game Boy, the CPU is recreated, the registers of the GPU are reset and the boot ROM runs again.
+// A HardReset also recreates the MMU and GPU, which refills RAM and clears VRAM and OAM.
+func (e *Emulator) Reset(kind ResetKind) {
+	if kind == HardReset || e.mmu == nil {
+		e.mmu = mmu.New(e.cartridge.ROM, e.boot, e.model.IsCGB())
+		e.mmu.Fill(e.options.RAMFill, e.ramSeed())
+		e.gpu = gpu.New(e.mmu, e.colorMode())
+	} else {
+		e.mmu.Reset()
+		e.gpu.Reset()
+	}
 
-		// handle input
-		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch e := event.(type) {
-			case *sdl.QuitEvent:
-				return
-
-			case *sdl.KeyboardEvent:
-				switch e.Type {
-				case sdl.KEYDOWN:
-					// Key press
-				case sdl.KEYUP:
-					// Key release
-				}
-			}
-		}
+	e.cpu = cpu.New(e.mmu)
+	e.gpu.SetRenderer(e.options.Renderer)
+	e.gpu.SetHiddenLayers(e.options.HiddenLayers)
+	e.gpu.SetOverlays(e.options.Overlays)
+	if e.model.IsCGB() && !e.colorMode() {
+		// The CGB boot ROM colorizes DMG cartridges.  This is done here for both the boot ROM and the skip path since
+		// DMG mode on the CGB is rendered with the DMG colors of the GPU.
+		e.gpu.SetDMGColors(colorize(e.cartridge.Header, e.options.PaletteCombo))
+	} else {
+		e.gpu.SetDMGColors(e.palette.BG, e.palette.OBJ0, e.palette.OBJ1)
+	}
+
+	e.joypad.ram = e.mmu
+	e.mmu.Map(0xff00, 0xff00, e.joypad)
+	e.serial.ram = e.mmu
+	e.mmu.Map(0xff01, 0xff02, e.serial)
+	e.mmu.Map(0xff4d, 0xff4d, e.cpu.Speed())
+	e.mmu.Map(0x8000, 0x9fff, e.gpu)
+	e.mmu.Map(0xfe00, 0xfeff, e.gpu)
+	e.mmu.Map(0xff40, 0xff4b, e.gpu)
+	e.mmu.Map(0xff4f, 0xff4f, e.gpu)
+	e.mmu.Map(0xff68, 0xff6b, e.gpu)
+	e.mmu.Intercept(e.cheats)
+
+	if e.mmu.BootROMEnabled() {
+		// The boot ROM starts from the power-on state
+		e.cpu.SetRegisters(cpu.Registers{})
+		return
+	}
+
+	e.cpu.SetRegisters(postBootRegisters(e.model, e.cartridge.Header))
+	for _, r := range postBootIO(e.model) {
+		e.mmu.Write(r.a, r.v)
+	}
+}
 
-		e.frame()
+// ramSeed returns the seed to fill RAM with, picking and logging one if none was provided
+func (e *Emulator) ramSeed() int64 {
+	if e.options.RAMFill != mmu.FillRandom {
+		return 0
+	}
+	if e.options.RAMSeed == 0 {
+		e.options.RAMSeed = time.Now().UnixNano()
+		log.Printf("Filling RAM with seed %d", e.options.RAMSeed)
 	}
+	return e.options.RAMSeed
 }
 
 const (
-	// The CPU runs as 4.194304 MHz with a vsync of 59.73 Hz, which is ~70221.06144316 cycles per frame
-	// To avoid working with a float we make 59.73 a whole number and shift the cpu speed out two places
-	// resulting an int with a value of 70221
-	cyclesPerFrame = 419430400 / 5973
+	// The PPU draws 154 lines of 456 dots each per frame, at 4.194304 MHz this gives a vsync of ~59.73 Hz
+	// Frame timing is counted in dots rather than CPU cycles since the dot clock does not change in double speed mode
+	dotsPerFrame = 154 * 456
 )
 
-func (e *Emulator) frame() {
-	var cycles uint32
-	for cycles < cyclesPerFrame {
-		cycles += uint32(e.cpu.Next())
+// dots converts a number of CPU cycles into PPU dots.  Each CPU cycle is 4 dots at normal speed and 2 dots at
+// double speed.
+func (e *Emulator) dots(cycles uint8) uint32 {
+	if e.cpu.DoubleSpeed() {
+		return uint32(cycles) * 2
+	}
+	return uint32(cycles) * 4
+}
+
+// Step executes a single CPU instruction and advances the GPU by the same time, it returns the number of cycles taken
+func (e *Emulator) Step() uint8 {
+	cycles := e.cpu.Next()
+	d := e.dots(cycles)
+
+	// A frame ends when the GPU enters VBlank, while the LCD is off a frame lasts as long as it would with it on
+	e.frameDots += d
+	if e.gpu.Next(int(d)) || e.frameDots >= dotsPerFrame {
+		e.endFrame()
+	}
+	return cycles
+}
+
+// RunFrame reads the buttons from the InputSource and runs until the next frame is complete
+func (e *Emulator) RunFrame() {
+	if e.input != nil {
+		// Pressing a button is what wakes the CPU from STOP
+		if e.joypad.set(e.input.Buttons()) {
+			e.cpu.Wake()
+		}
+	}
+
+	frames := e.frames
+	for e.frames == frames {
+		e.Step()
+	}
+}
+
+// Read returns the byte at an address as the CPU would read it
+func (e *Emulator) Read(a uint16) uint8 {
+	return e.mmu.Read(a)
+}
+
+// Frames returns the number of frames completed since the emulator was created
+func (e *Emulator) Frames() uint64 {
+	return e.frames
+}
+
+// Frame returns the last completed frame
+func (e *Emulator) Frame() *gpu.Frame {
+	return e.gpu.Frame()
+}
+
+// endFrame is called when a frame is complete
+func (e *Emulator) endFrame() {
+	e.frameDots = 0
+	e.frames++
+
+	// GameShark codes are applied once per frame during VBlank
+	e.cheats.Apply(e.mmu)
+
+	if e.video != nil {
+		e.video.Frame(e.gpu.Frame())
+	}
+	if e.recorder != nil {
+		e.recorder.Frame(e.gpu.Frame())
 	}
 
 	now := time.Now().Second()
@@ -0,0 +1,245 @@
+package emulator
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// testSink records the frames and provides the buttons
+type testSink struct {
+	frames  int
+	buttons Buttons
+}
+
+func (s *testSink) Frame(frame *gpu.Frame) {
+	s.frames++
+}
+
+func (s *testSink) Buttons() Buttons {
+	return s.buttons
+}
+
+func TestEmulator(t *testing.T) {
+	// newEmulator starts a ROM that stops the CPU right away, the GPU keeps running.  The entry point and the logo are
+	// filled with STOP so that the CPU stops again each time a button wakes it up.
+	newEmulator := func(t *testing.T) *Emulator {
+		rom := testROM()
+		for a := 0x0100; a < 0x0134; a++ {
+			rom[a] = 0x10
+		}
+		e, err := New(bytes.NewReader(rom), Options{SkipBootROM: true})
+		require.NoError(t, err)
+		return e
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "RunFrame",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				sink := &testSink{}
+				e.SetVideoSink(sink)
+
+				e.RunFrame()
+				e.RunFrame()
+				require.EqualValues(t, 2, e.Frames())
+				require.Equal(t, 2, sink.frames)
+
+				// Without a sink frames still complete
+				e.SetVideoSink(nil)
+				e.RunFrame()
+				require.EqualValues(t, 3, e.Frames())
+			},
+		},
+		{
+			name: "Step",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				dots := 0
+				for e.Frames() == 0 {
+					dots += int(e.Step()) * 4
+				}
+				require.LessOrEqual(t, dots, dotsPerFrame)
+			},
+		},
+		{
+			name: "LCDOff",
+			test: func(t *testing.T) {
+				// Frames last as long while the LCD is off
+				e := newEmulator(t)
+				e.mmu.Write(0xff40, 0x00)
+				dots := 0
+				for e.Frames() == 0 {
+					dots += int(e.Step()) * 4
+				}
+				require.Equal(t, dotsPerFrame, dots)
+			},
+		},
+		{
+			name: "Joypad",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				sink := &testSink{buttons: ButtonStart | ButtonUp}
+				e.SetInputSource(sink)
+				e.mmu.Write(0xff0f, 0x00)
+				e.RunFrame()
+
+				// Clearing a select bit reads that group, held buttons read as 0
+				e.mmu.Write(0xff00, selectActions)
+				require.EqualValues(t, 0xeb, e.mmu.Read(0xff00))
+				e.mmu.Write(0xff00, selectDirections)
+				require.EqualValues(t, 0xd7, e.mmu.Read(0xff00))
+				e.mmu.Write(0xff00, selectActions|selectDirections)
+				require.EqualValues(t, 0xff, e.mmu.Read(0xff00))
+
+				// Pressing a button in a selected group requests the joypad interrupt
+				e.mmu.Write(0xff0f, 0x00)
+				e.mmu.Write(0xff00, selectDirections)
+				sink.buttons |= ButtonA
+				e.RunFrame()
+				require.NotZero(t, e.mmu.Read(0xff0f)&interruptJoypad)
+			},
+		},
+		{
+			name: "TruncatedROM",
+			test: func(t *testing.T) {
+				// A truncated ROM is loaded with a warning, reads past its end see an open bus
+				rom := testROM()[:0x0150]
+				rom[0x0100] = 0x10
+				e, err := New(bytes.NewReader(rom), Options{SkipBootROM: true})
+				require.NoError(t, err)
+				require.NotEmpty(t, e.Cartridge().Warnings)
+
+				e.RunFrame()
+				require.EqualValues(t, 0x10, e.Read(0x0100))
+				require.EqualValues(t, 0xff, e.Read(0x0200))
+				require.EqualValues(t, 0xff, e.Read(0x4000))
+				require.EqualValues(t, 0xff, e.Read(0x7fff))
+			},
+		},
+		{
+			name: "Reset",
+			test: func(t *testing.T) {
+				// write fills work RAM, VRAM and OAM with the LCD off so that the GPU lets the CPU access them
+				write := func(e *Emulator) {
+					e.mmu.Write(0xff40, 0x00)
+					e.mmu.Write(0xc000, 0x12)
+					e.mmu.Write(0x8000, 0x34)
+					e.mmu.Write(0xfe00, 0x56)
+				}
+				// requireMemory checks work RAM, VRAM and OAM with the LCD off
+				requireMemory := func(e *Emulator, ram, vram, oam uint8) {
+					e.mmu.Write(0xff40, 0x00)
+					require.Equal(t, ram, e.Read(0xc000))
+					require.Equal(t, vram, e.Read(0x8000))
+					require.Equal(t, oam, e.Read(0xfe00))
+				}
+
+				e := newEmulator(t)
+				write(e)
+				e.Reset(SoftReset)
+				require.EqualValues(t, 0x91, e.Read(0xff40))
+				requireMemory(e, 0x12, 0x34, 0x56)
+
+				e.Reset(HardReset)
+				requireMemory(e, 0x00, 0x00, 0x00)
+			},
+		},
+		{
+			name: "Screenshot",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				e.mmu.Write(0xff40, 0x00)
+				e.RunFrame()
+
+				// The LCD is off, so the frame is the lightest color of the palette
+				img := e.Screenshot()
+				require.Equal(t, image.Rect(0, 0, gpu.Width, gpu.Height), img.Bounds())
+				require.Equal(t, e.Palette().BG[0], e.Frame()[0][0])
+				requireColor(t, e.Palette().BG[0], img.At(0, 0))
+
+				scaled := e.ScaledScreenshot(3)
+				require.Equal(t, image.Rect(0, 0, gpu.Width*3, gpu.Height*3), scaled.Bounds())
+				requireColor(t, e.Palette().BG[0], scaled.At(gpu.Width*3-1, gpu.Height*3-1))
+
+				// Screenshots are saved next to the ROM with the time added
+				dir := t.TempDir()
+				e.options.BasePath = filepath.Join(dir, "game")
+				path, err := e.TakeScreenshot()
+				require.NoError(t, err)
+				require.Regexp(t, `game-\d{8}-\d{6}\.\d{3}\.png$`, path)
+
+				f, err := os.Open(path)
+				require.NoError(t, err)
+				defer f.Close()
+				saved, err := png.Decode(f)
+				require.NoError(t, err)
+				require.Equal(t, img.Bounds(), saved.Bounds())
+				requireColor(t, e.Palette().BG[0], saved.At(80, 72))
+			},
+		},
+		{
+			name: "DebugViews",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				e.options.BasePath = filepath.Join(t.TempDir(), "game")
+				paths, err := e.SaveDebugViews()
+				require.NoError(t, err)
+				require.Len(t, paths, 7)
+				for _, path := range paths {
+					require.FileExists(t, path)
+				}
+
+				table, err := os.ReadFile(e.options.BasePath + "-oam.txt")
+				require.NoError(t, err)
+				require.Len(t, bytes.Split(bytes.TrimSpace(table), []byte("\n")), 40)
+			},
+		},
+		{
+			name: "Serial",
+			test: func(t *testing.T) {
+				e := newEmulator(t)
+				var out bytes.Buffer
+				e.SetSerialOutput(&out)
+				e.mmu.Write(0xff0f, 0x00)
+
+				// A transfer with the internal clock completes at once
+				e.mmu.Write(0xff01, 'P')
+				e.mmu.Write(0xff02, 0x81)
+				require.Equal(t, "P", out.String())
+				require.EqualValues(t, 0xff, e.Read(0xff01))
+				require.EqualValues(t, 0x7f, e.Read(0xff02))
+				require.NotZero(t, e.Read(0xff0f)&interruptSerial)
+
+				// One with the external clock waits for a partner that never comes
+				e.mmu.Write(0xff01, 'F')
+				e.mmu.Write(0xff02, 0x80)
+				require.Equal(t, "P", out.String())
+				require.EqualValues(t, 0xfe, e.Read(0xff02))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
+
+// requireColor asserts that an image color is the given gpu.Color
+func requireColor(t *testing.T, expected gpu.Color, actual color.Color) {
+	r, g, b, _ := expected.RGBA()
+	ar, ag, ab, _ := actual.RGBA()
+	require.Equal(t, [3]uint32{r, g, b}, [3]uint32{ar, ag, ab})
+}
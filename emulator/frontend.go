@@ -0,0 +1,48 @@
+package emulator
+
+import "github.com/borgstrom/ebgb/gpu"
+
+// VideoSink receives every frame the GPU completes.  The frame is reused for the next frame, a sink that keeps it
+// around has to copy it.
+type VideoSink interface {
+	Frame(frame *gpu.Frame)
+}
+
+// AudioSink receives the audio output as interleaved stereo samples at SampleRate
+type AudioSink interface {
+	Samples(samples []int16)
+}
+
+// SampleRate is the rate of the samples passed to an AudioSink
+const SampleRate = 48000
+
+// InputSource reports the buttons that are held, it is polled once at the start of every frame
+type InputSource interface {
+	Buttons() Buttons
+}
+
+// Recorder receives every frame and all audio, in addition to the video and audio sinks
+type Recorder interface {
+	VideoSink
+	AudioSink
+}
+
+// SetVideoSink sets where completed frames are sent, nil discards them
+func (e *Emulator) SetVideoSink(v VideoSink) {
+	e.video = v
+}
+
+// SetAudioSink sets where audio is sent, nil discards it.  There is no APU yet so the sink receives nothing.
+func (e *Emulator) SetAudioSink(a AudioSink) {
+	e.audio = a
+}
+
+// SetInputSource sets where the state of the buttons is read from, with nil no buttons are held
+func (e *Emulator) SetInputSource(i InputSource) {
+	e.input = i
+}
+
+// SetRecorder sets a recorder that is sent the same frames and audio as the sinks, nil stops sending them
+func (e *Emulator) SetRecorder(r Recorder) {
+	e.recorder = r
+}
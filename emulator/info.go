@@ -0,0 +1,187 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// cartridgeTypes maps the cartridge type at 0x0147 to the hardware on the cartridge
+// See: https://gbdev.io/pandocs/The_Cartridge_Header.html#0147--cartridge-type
+var cartridgeTypes = map[uint8]string{
+	0x00: "ROM ONLY",
+	0x01: "MBC1",
+	0x02: "MBC1+RAM",
+	0x03: "MBC1+RAM+BATTERY",
+	0x05: "MBC2",
+	0x06: "MBC2+BATTERY",
+	0x08: "ROM+RAM",
+	0x09: "ROM+RAM+BATTERY",
+	0x0b: "MMM01",
+	0x0c: "MMM01+RAM",
+	0x0d: "MMM01+RAM+BATTERY",
+	0x0f: "MBC3+TIMER+BATTERY",
+	0x10: "MBC3+TIMER+RAM+BATTERY",
+	0x11: "MBC3",
+	0x12: "MBC3+RAM",
+	0x13: "MBC3+RAM+BATTERY",
+	0x19: "MBC5",
+	0x1a: "MBC5+RAM",
+	0x1b: "MBC5+RAM+BATTERY",
+	0x1c: "MBC5+RUMBLE",
+	0x1d: "MBC5+RUMBLE+RAM",
+	0x1e: "MBC5+RUMBLE+RAM+BATTERY",
+	0x20: "MBC6",
+	0x22: "MBC7+SENSOR+RUMBLE+RAM+BATTERY",
+	0xfc: "POCKET CAMERA",
+	0xfd: "BANDAI TAMA5",
+	0xfe: "HuC3",
+	0xff: "HuC1+RAM+BATTERY",
+}
+
+// ramSizes maps the RAM size at 0x0149 to the number of 8KiB banks of cartridge RAM
+var ramSizes = map[uint8]int{
+	0x00: 0,
+	0x02: 1,
+	0x03: 4,
+	0x04: 16,
+	0x05: 8,
+}
+
+// CartridgeInfo is the decoded cartridge header, in a form suited for display
+type CartridgeInfo struct {
+	Title        string `json:"title"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	CGBFlag      uint8  `json:"cgb_flag"`
+	CGB          string `json:"cgb"`
+	SGB          bool   `json:"sgb"`
+
+	Type     uint8  `json:"type"`
+	TypeName string `json:"type_name"`
+	MBC      string `json:"mbc"`
+
+	ROMSize  int `json:"rom_size"`
+	ROMBanks int `json:"rom_banks"`
+	RAMSize  int `json:"ram_size"`
+	RAMBanks int `json:"ram_banks"`
+
+	Destination     string `json:"destination"`
+	OldLicenseeCode uint8  `json:"old_licensee_code"`
+	OldLicensee     string `json:"old_licensee"`
+	NewLicenseeCode string `json:"new_licensee_code,omitempty"`
+	NewLicensee     string `json:"new_licensee,omitempty"`
+	MaskROMVersion  uint8  `json:"mask_rom_version"`
+
+	LogoValid           bool `json:"logo_valid"`
+	HeaderChecksumValid bool `json:"header_checksum_valid"`
+	GlobalChecksumValid bool `json:"global_checksum_valid"`
+	SizeValid           bool `json:"size_valid"`
+
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Info decodes the cartridge header
+func (c *Cartridge) Info() CartridgeInfo {
+	h := c.Header
+	info := CartridgeInfo{
+		CGBFlag:        h.CGB(),
+		SGB:            h.SGB == 0x03,
+		Type:           h.Type,
+		TypeName:       lookup(cartridgeTypes, h.Type),
+		Destination:    "Japan",
+		MaskROMVersion: h.MaskROMVersion,
+
+		OldLicenseeCode: h.OldLicenseeCode,
+		OldLicensee:     lookup(oldLicensees, h.OldLicenseeCode),
+	}
+
+	info.Title, info.Manufacturer = splitTitle(h.Title)
+
+	switch {
+	case h.CGB()&0xc0 == 0xc0:
+		info.CGB = "required"
+	case h.CGB()&0x80 != 0:
+		info.CGB = "enhanced"
+	default:
+		info.CGB = "none"
+	}
+
+	info.MBC = strings.SplitN(info.TypeName, "+", 2)[0]
+	if info.MBC == "ROM ONLY" || info.MBC == "ROM" {
+		info.MBC = "none"
+	}
+
+	if size, ok := h.ROMBytes(); ok {
+		info.ROMSize, info.ROMBanks = size, size/0x4000
+	}
+	info.RAMBanks = ramSizes[h.RAMSize]
+	info.RAMSize = info.RAMBanks * 0x2000
+
+	if h.DestinationCode != 0x00 {
+		info.Destination = "Overseas"
+	}
+
+	if h.OldLicenseeCode == 0x33 {
+		info.NewLicenseeCode = string(h.NewLicenseeCode[:])
+		info.NewLicensee = newLicensees[info.NewLicenseeCode]
+		if info.NewLicensee == "" {
+			info.NewLicensee = "Unknown"
+		}
+	}
+
+	info.LogoValid, info.HeaderChecksumValid, info.GlobalChecksumValid, info.SizeValid = true, true, true, true
+	for _, p := range validate(c.ROM, h) {
+		info.Problems = append(info.Problems, p.Error())
+		switch {
+		case errors.Is(p, ErrLogo):
+			info.LogoValid = false
+		case errors.Is(p, ErrHeaderChecksum):
+			info.HeaderChecksumValid = false
+		case errors.Is(p, ErrGlobalChecksum):
+			info.GlobalChecksumValid = false
+		default:
+			info.SizeValid = false
+		}
+	}
+
+	return info
+}
+
+// splitTitle returns the title and the manufacturer code.  Later cartridges shortened the title to 11 bytes and
+// store a 4 character manufacturer code and the CGB flag in the last 5 bytes.
+func splitTitle(title [16]uint8) (string, string) {
+	manufacturer := title[11:15]
+	if title[15]&0x80 != 0 && title[10] == 0x00 && isManufacturer(manufacturer) {
+		return cleanTitle(title[:11]), string(manufacturer)
+	}
+	if title[15]&0x80 != 0 {
+		return cleanTitle(title[:15]), ""
+	}
+	return cleanTitle(title[:]), ""
+}
+
+// isManufacturer reports if the bytes look like a manufacturer code, which is 4 uppercase letters or digits
+func isManufacturer(b []uint8) bool {
+	for _, c := range b {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanTitle returns the title up to the first padding byte
+func cleanTitle(b []uint8) string {
+	if i := bytes.IndexByte(b, 0x00); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func lookup(table map[uint8]string, code uint8) string {
+	if name, ok := table[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%#02x)", code)
+}
@@ -0,0 +1,62 @@
+package emulator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfo(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Header",
+			test: func(t *testing.T) {
+				rom := testROM()
+				copy(rom[0x0134:], "POKEMON\x00\x00\x00\x00BYTE\xc0")
+				rom[0x0144], rom[0x0145] = '0', '1'
+				rom[0x0147], rom[0x0148], rom[0x0149] = 0x1b, 0x00, 0x03
+				rom[0x014b] = 0x33
+				c, err := Load(bytes.NewReader(rom), nil, Lenient)
+				require.NoError(t, err)
+
+				i := c.Info()
+				require.Equal(t, "POKEMON", i.Title)
+				require.Equal(t, "BYTE", i.Manufacturer)
+				require.Equal(t, "required", i.CGB)
+				require.Equal(t, "MBC5", i.MBC)
+				require.Equal(t, 2, i.ROMBanks)
+				require.Equal(t, 32*1024, i.RAMSize)
+				require.Equal(t, "Nintendo Research & Development 1", i.NewLicensee)
+				require.True(t, i.LogoValid)
+				require.False(t, i.HeaderChecksumValid)
+			},
+		},
+		{
+			name: "Plain",
+			test: func(t *testing.T) {
+				c, err := Load(bytes.NewReader(testROM()), nil, Strict)
+				require.NoError(t, err)
+
+				i := c.Info()
+				require.Equal(t, "TESTROM", i.Title)
+				require.Equal(t, "none", i.CGB)
+				require.Equal(t, "none", i.MBC)
+				require.Equal(t, 0x8000, i.ROMSize)
+				require.Zero(t, i.RAMSize)
+				require.Equal(t, "Japan", i.Destination)
+				require.Empty(t, i.NewLicensee)
+				require.True(t, i.HeaderChecksumValid)
+				require.True(t, i.GlobalChecksumValid)
+				require.Empty(t, i.Problems)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
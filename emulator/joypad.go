@@ -0,0 +1,65 @@
+package emulator
+
+import "github.com/borgstrom/ebgb/mmu"
+
+// Buttons is a set of held buttons
+type Buttons uint8
+
+const (
+	ButtonRight Buttons = 1 << iota
+	ButtonLeft
+	ButtonUp
+	ButtonDown
+	ButtonA
+	ButtonB
+	ButtonSelect
+	ButtonStart
+)
+
+// P1 bits that select which half of the buttons is read, a group is selected when its bit is cleared
+const (
+	selectDirections = 0x10
+	selectActions    = 0x20
+)
+
+// interruptJoypad is the bit of the joypad interrupt in IF
+const interruptJoypad = 0x10
+
+// joypad implements the P1 register (0xff00) which reads the buttons one half at a time
+type joypad struct {
+	ram      mmu.ReadWriter
+	selected uint8
+	buttons  Buttons
+}
+
+func (j *joypad) Read(a uint16) uint8 {
+	return 0xc0 | j.selected | ^j.lines()&0x0f
+}
+
+func (j *joypad) Write(a uint16, v uint8) {
+	j.selected = v & 0x30
+}
+
+// lines returns the held buttons of the selected groups in the lower 4 bits, a held button reads as 0 in P1
+func (j *joypad) lines() uint8 {
+	var lines uint8
+	if j.selected&selectDirections == 0 {
+		lines |= uint8(j.buttons) & 0x0f
+	}
+	if j.selected&selectActions == 0 {
+		lines |= uint8(j.buttons) >> 4
+	}
+	return lines
+}
+
+// set updates the held buttons, a button that is pressed in a selected group requests the joypad interrupt.  It
+// returns true if a button was pressed.
+func (j *joypad) set(b Buttons) bool {
+	before := j.lines()
+	j.buttons = b
+	if j.lines()&^before == 0 {
+		return false
+	}
+	j.ram.Write(0xff0f, j.ram.Read(0xff0f)|interruptJoypad)
+	return true
+}
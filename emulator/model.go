@@ -0,0 +1,88 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Model identifies a Game Boy hardware revision
+type Model int
+
+const (
+	// Auto selects the model from the cartridge header
+	Auto Model = iota
+	// DMG is the original Game Boy
+	DMG
+	// DMG0 is the early Japanese revision of the DMG, which has its own boot ROM
+	DMG0
+	// MGB is the Game Boy Pocket
+	MGB
+	// SGB is the Super Game Boy
+	SGB
+	// CGB is the Game Boy Color
+	CGB
+	// AGB is the Game Boy Advance running in Game Boy Color mode
+	AGB
+)
+
+var modelNames = map[Model]string{
+	Auto: "auto",
+	DMG:  "dmg",
+	DMG0: "dmg0",
+	MGB:  "mgb",
+	SGB:  "sgb",
+	CGB:  "cgb",
+	AGB:  "agb",
+}
+
+func (m Model) String() string {
+	if name, ok := modelNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Model(%d)", int(m))
+}
+
+// IsCGB reports if the model has the Game Boy Color hardware
+func (m Model) IsCGB() bool {
+	return m == CGB || m == AGB
+}
+
+// SelectModel returns the model that best suits a cartridge, based on the CGB and SGB flags in its header
+func SelectModel(header CartridgeHeader) Model {
+	// Bit 7 of the CGB flag is set for both CGB enhanced (0x80) and CGB only (0xc0) cartridges
+	if header.CGB()&0x80 != 0 {
+		return CGB
+	}
+
+	// SGB functions are only enabled when the old licensee code indicates that the new licensee code is used
+	if header.SGB == 0x03 && header.OldLicenseeCode == 0x33 {
+		return SGB
+	}
+
+	return DMG
+}
+
+// ParseModel returns the Model for a name such as "dmg" or "cgb", names are case insensitive
+func ParseModel(name string) (Model, error) {
+	for model, n := range modelNames {
+		if strings.EqualFold(n, name) {
+			return model, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown model %q", name)
+}
+
+// MarshalText implements encoding.TextMarshaler so that models can be used as keys in the config file
+func (m Model) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler so that models can be used as keys in the config file
+func (m *Model) UnmarshalText(text []byte) error {
+	model, err := ParseModel(string(text))
+	if err != nil {
+		return err
+	}
+	*m = model
+	return nil
+}
@@ -0,0 +1,40 @@
+package emulator
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectModel(t *testing.T) {
+	// header loads a ROM with the CGB flag, SGB flag and old licensee code, so that the header fields are read from
+	// their offsets in the ROM
+	header := func(cgb, sgb, licensee uint8) CartridgeHeader {
+		rom := testROM()
+		rom[0x0143], rom[0x0146], rom[0x014b] = cgb, sgb, licensee
+		c, err := Load(bytes.NewReader(rom), nil, Lenient)
+		require.NoError(t, err)
+		return c.Header
+	}
+
+	var tests = []struct {
+		name   string
+		header CartridgeHeader
+		model  Model
+	}{
+		{name: "CGBEnhanced", header: header(0x80, 0x00, 0x01), model: CGB},
+		{name: "CGBOnly", header: header(0xc0, 0x00, 0x01), model: CGB},
+		{name: "CGBAndSGB", header: header(0x80, 0x03, 0x33), model: CGB},
+		{name: "SGB", header: header(0x00, 0x03, 0x33), model: SGB},
+		{name: "SGBOldLicensee", header: header(0x00, 0x03, 0x01), model: DMG},
+		{name: "DMG", header: header(0x00, 0x00, 0x01), model: DMG},
+		{name: "TitleLetter", header: header('X', 0x00, 0x01), model: DMG},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.model, SelectModel(test.header))
+		})
+	}
+}
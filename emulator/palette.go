@@ -0,0 +1,185 @@
+package emulator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// ErrPalette is returned when a palette file can't be parsed
+var ErrPalette = errors.New("invalid palette")
+
+// Palette holds the colors of the four DMG shades, from lightest to darkest, for the background and window and for
+// both sprite palettes.  The colors are 15 bit like those of the CGB, so 24 bit colors lose their lowest 3 bits per
+// component: 0x9bbc0f is shown as 0x9cbd08.
+type Palette struct {
+	Name string
+	BG   [4]gpu.Color
+	OBJ0 [4]gpu.Color
+	OBJ1 [4]gpu.Color
+}
+
+// NewPalette returns a Palette that uses the same colors for all layers
+func NewPalette(name string, colors [4]gpu.Color) Palette {
+	return Palette{Name: name, BG: colors, OBJ0: colors, OBJ1: colors}
+}
+
+// Presets are the built in palettes, the first one is the default
+var Presets = []Palette{
+	NewPalette("dmg", [4]gpu.Color{rgb24(0x9bbc0f), rgb24(0x8bac0f), rgb24(0x306230), rgb24(0x0f380f)}),
+	NewPalette("pocket", [4]gpu.Color{rgb24(0xc4cfa1), rgb24(0x8b956d), rgb24(0x4d533c), rgb24(0x1f1f1f)}),
+	NewPalette("light", [4]gpu.Color{rgb24(0x00b581), rgb24(0x009a71), rgb24(0x00694a), rgb24(0x004f3b)}),
+	NewPalette("contrast", [4]gpu.Color{rgb24(0xffffff), rgb24(0xaaaaaa), rgb24(0x555555), rgb24(0x000000)}),
+}
+
+// FindPalette returns the palette with the given name
+func FindPalette(palettes []Palette, name string) (Palette, bool) {
+	for _, p := range palettes {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Palette{}, false
+}
+
+// LoadPalette reads a palette file, the palette is named after the file without its extension
+func LoadPalette(path string) (Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Palette{}, err
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	p, err := ReadPalette(f, name)
+	if err != nil {
+		return Palette{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ReadPalette parses a JASC (.pal), GIMP (.gpl) or hex (.hex, one RRGGBB color per line) palette.  A palette with 4
+// colors is used for all layers, one with 12 colors holds the background, OBJ0 and OBJ1 colors in that order.  Colors
+// are quantized to 15 bits, see Palette.
+func ReadPalette(r io.Reader, name string) (Palette, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Palette{}, err
+	}
+
+	var colors []gpu.Color
+	var err error
+	switch {
+	case len(lines) > 0 && lines[0] == "JASC-PAL":
+		colors, err = readJASC(lines[1:])
+	case len(lines) > 0 && lines[0] == "GIMP Palette":
+		colors, err = readGIMP(lines[1:])
+	default:
+		colors, err = readHex(lines)
+	}
+	if err != nil {
+		return Palette{}, err
+	}
+
+	switch len(colors) {
+	case 4:
+		return NewPalette(name, [4]gpu.Color{colors[0], colors[1], colors[2], colors[3]}), nil
+	case 12:
+		p := Palette{Name: name}
+		copy(p.BG[:], colors[0:4])
+		copy(p.OBJ0[:], colors[4:8])
+		copy(p.OBJ1[:], colors[8:12])
+		return p, nil
+	}
+	return Palette{}, fmt.Errorf("%w: %d colors, expected 4 or 12", ErrPalette, len(colors))
+}
+
+// readJASC parses the lines of a JASC palette after its header: the version, the number of colors and a line of
+// decimal R G B values for each color
+func readJASC(lines []string) ([]gpu.Color, error) {
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("%w: missing JASC header", ErrPalette)
+	}
+	count, err := strconv.Atoi(lines[1])
+	if err != nil || count != len(lines)-2 {
+		return nil, fmt.Errorf("%w: bad JASC color count %q", ErrPalette, lines[1])
+	}
+
+	colors := make([]gpu.Color, 0, count)
+	for _, line := range lines[2:] {
+		c, err := parseRGB(strings.Fields(line))
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// readGIMP parses the lines of a GIMP palette after its header, they hold decimal R G B values followed by an
+// optional color name.  Comments and the Name and Columns fields are skipped.
+func readGIMP(lines []string) ([]gpu.Color, error) {
+	var colors []gpu.Color
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "Name:") || strings.HasPrefix(line, "Columns:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%w: bad GIMP color %q", ErrPalette, line)
+		}
+		c, err := parseRGB(fields[:3])
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, c)
+	}
+	return colors, nil
+}
+
+// readHex parses lines holding a color each as RRGGBB, optionally prefixed with #
+func readHex(lines []string) ([]gpu.Color, error) {
+	colors := make([]gpu.Color, 0, len(lines))
+	for _, line := range lines {
+		v, err := strconv.ParseUint(strings.TrimPrefix(line, "#"), 16, 24)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad hex color %q", ErrPalette, line)
+		}
+		colors = append(colors, rgb24(uint32(v)))
+	}
+	return colors, nil
+}
+
+// parseRGB parses decimal R, G and B components of 0 - 255
+func parseRGB(fields []string) (gpu.Color, error) {
+	if len(fields) != 3 {
+		return 0, fmt.Errorf("%w: bad color %q", ErrPalette, strings.Join(fields, " "))
+	}
+	var rgb [3]uint8
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("%w: bad color %q", ErrPalette, strings.Join(fields, " "))
+		}
+		rgb[i] = uint8(v)
+	}
+	return gpu.RGB(rgb[0]>>3, rgb[1]>>3, rgb[2]>>3), nil
+}
+
+// rgb24 converts a 24 bit 0xRRGGBB color to a gpu.Color, keeping the top 5 bits of each component
+func rgb24(c uint32) gpu.Color {
+	return gpu.RGB(uint8(c>>19), uint8(c>>11), uint8(c>>3))
+}
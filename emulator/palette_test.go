@@ -0,0 +1,108 @@
+package emulator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+func TestReadPalette(t *testing.T) {
+	gray := [4]gpu.Color{gpu.RGB(31, 31, 31), gpu.RGB(21, 21, 21), gpu.RGB(10, 10, 10), gpu.RGB(0, 0, 0)}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "JASC",
+			test: func(t *testing.T) {
+				p, err := ReadPalette(strings.NewReader("JASC-PAL\r\n0100\r\n4\r\n255 255 255\r\n170 170 170\r\n85 85 85\r\n0 0 0\r\n"), "gray")
+				require.NoError(t, err)
+				require.Equal(t, NewPalette("gray", gray), p)
+			},
+		},
+		{
+			name: "GIMP",
+			test: func(t *testing.T) {
+				p, err := ReadPalette(strings.NewReader("GIMP Palette\nName: Gray\nColumns: 4\n#\n255 255 255\tWhite\n"+
+					"170 170 170\n 85  85  85\n  0   0   0\tBlack\n"), "gray")
+				require.NoError(t, err)
+				require.Equal(t, NewPalette("gray", gray), p)
+			},
+		},
+		{
+			name: "Hex",
+			test: func(t *testing.T) {
+				p, err := ReadPalette(strings.NewReader("ffffff\n#aaaaaa\n555555\n000000\n"), "gray")
+				require.NoError(t, err)
+				require.Equal(t, NewPalette("gray", gray), p)
+			},
+		},
+		{
+			name: "Layers",
+			test: func(t *testing.T) {
+				p, err := ReadPalette(strings.NewReader("ffffff\naaaaaa\n555555\n000000\n"+
+					"ff0000\naaaaaa\n555555\n000000\n"+
+					"0000ff\naaaaaa\n555555\n000000\n"), "layers")
+				require.NoError(t, err)
+				require.Equal(t, gray, p.BG)
+				require.Equal(t, gpu.RGB(31, 0, 0), p.OBJ0[0])
+				require.Equal(t, gpu.RGB(0, 0, 31), p.OBJ1[0])
+			},
+		},
+		{
+			name: "Quantized",
+			test: func(t *testing.T) {
+				// 24 bit colors are reduced to 15 bits, which shows as 0x9cbd08 when scaled back to 24 bits
+				p, err := ReadPalette(strings.NewReader("9bbc0f\n8bac0f\n306230\n0f380f\n"), "dmg")
+				require.NoError(t, err)
+				require.Equal(t, Presets[0], p)
+				r, g, b := p.BG[0].RGB8()
+				require.Equal(t, [3]uint8{0x9c, 0xbd, 0x08}, [3]uint8{r, g, b})
+			},
+		},
+		{
+			name: "RoundTrip",
+			test: func(t *testing.T) {
+				// Writing the presets out as 24 bit colors and loading them again gives the same palettes
+				dir := t.TempDir()
+				for _, preset := range Presets {
+					var b strings.Builder
+					for _, colors := range [][4]gpu.Color{preset.BG, preset.OBJ0, preset.OBJ1} {
+						for _, c := range colors {
+							r, g, b8 := c.RGB8()
+							fmt.Fprintf(&b, "%02x%02x%02x\n", r, g, b8)
+						}
+					}
+					path := filepath.Join(dir, preset.Name+".hex")
+					require.NoError(t, ioutil.WriteFile(path, []byte(b.String()), 0644))
+
+					p, err := LoadPalette(path)
+					require.NoError(t, err)
+					require.Equal(t, preset, p)
+				}
+			},
+		},
+		{
+			name: "Invalid",
+			test: func(t *testing.T) {
+				_, err := ReadPalette(strings.NewReader("ffffff\n000000\n"), "short")
+				require.ErrorIs(t, err, ErrPalette)
+				_, err = ReadPalette(strings.NewReader("JASC-PAL\n0100\n5\n0 0 0\n"), "count")
+				require.ErrorIs(t, err, ErrPalette)
+				_, err = ReadPalette(strings.NewReader("JASC-PAL\n0100\n1\n0 0 256\n"), "range")
+				require.ErrorIs(t, err, ErrPalette)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
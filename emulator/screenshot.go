@@ -0,0 +1,72 @@
+package emulator
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"time"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// Screenshot returns the last completed frame at 160x144, with the colors of the active palette.  The colors are raw
+// unless SetScreenshotCorrection selected a color correction.
+func (e *Emulator) Screenshot() image.Image {
+	return e.ScaledScreenshot(1)
+}
+
+// ScaledScreenshot returns the last completed frame with each pixel scaled up to a square of scale by scale pixels
+func (e *Emulator) ScaledScreenshot(scale int) image.Image {
+	return e.gpu.Frame().CorrectedImage(scale, e.screenshotCorrection)
+}
+
+// SetScreenshotCorrection sets the color correction applied to screenshots
+func (e *Emulator) SetScreenshotCorrection(c gpu.Correction) {
+	e.screenshotCorrection = c
+}
+
+// LCDCorrection returns the color correction for the LCD of the emulated model.  The CGB and AGB have their own curves,
+// the colors of the other models come from palettes that are already meant for a monitor.
+func (e *Emulator) LCDCorrection() gpu.Correction {
+	switch e.model {
+	case CGB:
+		return gpu.CGBColors
+	case AGB:
+		return gpu.AGBColors
+	}
+	return gpu.RawColors
+}
+
+// SaveScreenshot writes the last completed frame to a PNG file, scaled by Options.ScreenshotScale
+func (e *Emulator) SaveScreenshot(path string) error {
+	return SavePNG(path, e.ScaledScreenshot(e.options.ScreenshotScale))
+}
+
+// SavePNG writes an image to a PNG file
+func SavePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// TakeScreenshot saves the last completed frame next to the ROM, named after it with the time added, and returns the
+// path of the file
+func (e *Emulator) TakeScreenshot() (string, error) {
+	path := screenshotPath(e.options.BasePath, time.Now())
+	return path, e.SaveScreenshot(path)
+}
+
+// screenshotPath returns the path of a screenshot taken at t, the milliseconds keep screenshots taken in quick
+// succession apart
+func screenshotPath(base string, t time.Time) string {
+	if base == "" {
+		base = "screenshot"
+	}
+	return base + "-" + t.Format("20060102-150405.000") + ".png"
+}
@@ -0,0 +1,52 @@
+package emulator
+
+import (
+	"io"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// interruptSerial is the bit of the serial interrupt in IF
+const interruptSerial = 0x08
+
+// serial implements the serial port registers SB (0xff01) and SC (0xff02).  There is never a link partner, so a
+// transfer using the internal clock completes at once and shifts in 0xff.  Transfers using the external clock never
+// complete.
+type serial struct {
+	ram mmu.ReadWriter
+	sb  uint8
+	sc  uint8
+	out io.Writer
+}
+
+func (s *serial) Read(a uint16) uint8 {
+	if a == 0xff01 {
+		return s.sb
+	}
+	// The unused bits of SC read as 1
+	return s.sc | 0x7e
+}
+
+func (s *serial) Write(a uint16, v uint8) {
+	if a == 0xff01 {
+		s.sb = v
+		return
+	}
+
+	s.sc = v
+	if v&0x81 != 0x81 {
+		return
+	}
+	if s.out != nil {
+		s.out.Write([]byte{s.sb})
+	}
+	s.sb = 0xff
+	s.sc &^= 0x80
+	s.ram.Write(0xff0f, s.ram.Read(0xff0f)|interruptSerial)
+}
+
+// SetSerialOutput sets where the bytes sent over the serial port are written, test ROMs use it to report results.
+// With nil they are discarded.
+func (e *Emulator) SetSerialOutput(w io.Writer) {
+	e.serial.out = w
+}
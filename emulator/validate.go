@@ -0,0 +1,109 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+var (
+	// ErrTruncated is returned when a ROM is smaller than the size in its header, or too small to hold a header
+	ErrTruncated = errors.New("rom is truncated")
+	// ErrOverdump is returned when a ROM is larger than the size in its header
+	ErrOverdump = errors.New("rom is overdumped")
+	// ErrROMSize is returned when the ROM size in the header is not a known value
+	ErrROMSize = errors.New("unknown rom size")
+	// ErrLogo is returned when the Nintendo logo in the header is damaged, the boot ROM locks up on these cartridges
+	ErrLogo = errors.New("nintendo logo mismatch")
+	// ErrHeaderChecksum is returned when the header checksum is wrong, the boot ROM locks up on these cartridges
+	ErrHeaderChecksum = errors.New("header checksum mismatch")
+	// ErrGlobalChecksum is returned when the checksum over the whole ROM is wrong, hardware does not verify it
+	ErrGlobalChecksum = errors.New("global checksum mismatch")
+)
+
+// Policy selects how Load handles a cartridge that fails validation
+type Policy int
+
+const (
+	// Lenient loads the cartridge and reports the problems in Cartridge.Warnings
+	Lenient Policy = iota
+	// Strict fails to load the cartridge with a *ValidationError
+	Strict
+)
+
+// ValidationError is returned by Load in Strict mode, it holds all problems that were found with the cartridge
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.Error()
+	}
+	return "invalid cartridge: " + strings.Join(problems, ", ")
+}
+
+// Is allows errors.Is to match any of the problems, for example errors.Is(err, ErrHeaderChecksum)
+func (e *ValidationError) Is(target error) bool {
+	for _, p := range e.Problems {
+		if errors.Is(p, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// nintendoLogo is the logo every cartridge must contain, the DMG boot ROM holds a copy to compare against
+var nintendoLogo = mmu.DMGBootROM[0xa8:0xd8]
+
+// validate checks the header and size of a ROM, returning every problem found
+func validate(rom []uint8, header CartridgeHeader) []error {
+	var problems []error
+
+	if size, ok := header.ROMBytes(); !ok {
+		problems = append(problems, fmt.Errorf("%w: %#02x", ErrROMSize, header.ROMSize))
+	} else if len(rom) < size {
+		problems = append(problems, fmt.Errorf("%w: %d bytes, header says %d", ErrTruncated, len(rom), size))
+	} else if len(rom) > size {
+		problems = append(problems, fmt.Errorf("%w: %d bytes, header says %d", ErrOverdump, len(rom), size))
+	}
+
+	if !bytes.Equal(header.Logo[:], nintendoLogo) {
+		problems = append(problems, ErrLogo)
+	}
+
+	if sum := HeaderChecksum(rom); sum != header.HeaderChecksum {
+		problems = append(problems, fmt.Errorf("%w: header says %#02x, computed %#02x",
+			ErrHeaderChecksum, header.HeaderChecksum, sum))
+	}
+
+	if sum, want := GlobalChecksum(rom), uint16(header.GlobalChecksum[0])<<8|uint16(header.GlobalChecksum[1]); sum != want {
+		problems = append(problems, fmt.Errorf("%w: header says %#04x, computed %#04x", ErrGlobalChecksum, want, sum))
+	}
+
+	return problems
+}
+
+// HeaderChecksum computes the checksum over the header bytes 0x0134 - 0x014c, the ROM must hold a full header
+func HeaderChecksum(rom []uint8) uint8 {
+	var sum uint8
+	for _, v := range rom[0x0134:0x014d] {
+		sum = sum - v - 1
+	}
+	return sum
+}
+
+// GlobalChecksum computes the sum of every byte of the ROM except the global checksum itself
+func GlobalChecksum(rom []uint8) uint16 {
+	var sum uint16
+	for i, v := range rom {
+		if i != 0x014e && i != 0x014f {
+			sum += uint16(v)
+		}
+	}
+	return sum
+}
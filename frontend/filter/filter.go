@@ -0,0 +1,285 @@
+// Package filter scales frames up for display on the CPU, blends consecutive frames for games that flicker sprites to
+// make them look transparent, and corrects the colors for the LCD being emulated
+package filter
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// Filter selects how each pixel of a frame is scaled up
+type Filter int
+
+const (
+	// Nearest leaves the pixels as they are, the frame is scaled up by the frontend
+	Nearest Filter = iota
+	// Scale2x and Scale3x smooth diagonal edges without blurring or adding colors
+	Scale2x
+	Scale3x
+	// LCD draws each pixel as a 3x3 dot with darker right and bottom edges, like the grid of the LCD
+	LCD
+)
+
+var filterNames = map[Filter]string{
+	Nearest: "nearest",
+	Scale2x: "scale2x",
+	Scale3x: "scale3x",
+	LCD:     "lcd",
+}
+
+func (f Filter) String() string {
+	if name, ok := filterNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Filter(%d)", int(f))
+}
+
+// ParseFilter returns the Filter for a name such as "nearest" or "scale2x"
+func ParseFilter(name string) (Filter, error) {
+	for f, n := range filterNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown filter %q", name)
+}
+
+// Next returns the filter after f, wrapping around to Nearest
+func (f Filter) Next() Filter {
+	return (f + 1) % Filter(len(filterNames))
+}
+
+// Scale returns the factor the filter scales frames up by
+func (f Filter) Scale() int {
+	switch f {
+	case Scale2x:
+		return 2
+	case Scale3x, LCD:
+		return 3
+	}
+	return 1
+}
+
+// Pipeline turns frames into images with a filter, optionally blending each frame with the one before it.  The colors
+// are corrected as the last step.
+type Pipeline struct {
+	filter     Filter
+	blend      bool
+	correction gpu.Correction
+
+	// previous is the last frame before blending, blended the frame that is filtered
+	previous    gpu.Frame
+	hasPrevious bool
+	blended     gpu.Frame
+
+	out *image.RGBA
+}
+
+// New returns a Pipeline that applies a filter, with frame blending if blend is set
+func New(f Filter, blend bool) *Pipeline {
+	p := &Pipeline{blend: blend}
+	p.SetFilter(f)
+	return p
+}
+
+// Filter returns the filter of the pipeline
+func (p *Pipeline) Filter() Filter {
+	return p.filter
+}
+
+// SetFilter changes the filter, the size of the images changes with its scale
+func (p *Pipeline) SetFilter(f Filter) {
+	p.filter = f
+	scale := f.Scale()
+	p.out = image.NewRGBA(image.Rect(0, 0, gpu.Width*scale, gpu.Height*scale))
+}
+
+// Blend reports if frames are blended
+func (p *Pipeline) Blend() bool {
+	return p.blend
+}
+
+// SetBlend turns frame blending on or off
+func (p *Pipeline) SetBlend(blend bool) {
+	p.blend = blend
+	p.hasPrevious = false
+}
+
+// Correction returns the color correction of the pipeline
+func (p *Pipeline) Correction() gpu.Correction {
+	return p.correction
+}
+
+// SetCorrection changes the color correction
+func (p *Pipeline) SetCorrection(c gpu.Correction) {
+	p.correction = c
+}
+
+// Apply filters a frame.  The image is reused by the next call.
+func (p *Pipeline) Apply(frame *gpu.Frame) *image.RGBA {
+	if p.blend {
+		frame = p.blendFrame(frame)
+	}
+
+	switch p.filter {
+	case Scale2x:
+		p.scale2x(frame)
+	case Scale3x:
+		p.scale3x(frame)
+	case LCD:
+		p.lcd(frame)
+	default:
+		p.nearest(frame)
+	}
+	return p.out
+}
+
+// blendFrame returns the average of the frame and the one before it.  The average is taken with the frame as it was
+// drawn, so a pixel that stops flickering doesn't leave a trail.
+func (p *Pipeline) blendFrame(frame *gpu.Frame) *gpu.Frame {
+	if !p.hasPrevious {
+		p.previous, p.hasPrevious = *frame, true
+	}
+	for y := range frame {
+		for x, c := range frame[y] {
+			p.blended[y][x] = average(c, p.previous[y][x])
+		}
+	}
+	p.previous = *frame
+	return &p.blended
+}
+
+// average returns the average of two colors, rounding up
+func average(a, b gpu.Color) gpu.Color {
+	ar, ag, ab := a.RGB5()
+	br, bg, bb := b.RGB5()
+	return gpu.RGB((ar+br+1)/2, (ag+bg+1)/2, (ab+bb+1)/2)
+}
+
+// set sets the pixel at x, y of the image to a color, applying the color correction
+func (p *Pipeline) set(x, y int, c gpu.Color) {
+	r, g, b := p.correction.RGB8(c)
+	i := y*p.out.Stride + x*4
+	p.out.Pix[i], p.out.Pix[i+1], p.out.Pix[i+2], p.out.Pix[i+3] = r, g, b, 0xff
+}
+
+// at returns the pixel at x, y of a frame, coordinates outside of the frame are clamped to its edges
+func at(frame *gpu.Frame, x, y int) gpu.Color {
+	switch {
+	case x < 0:
+		x = 0
+	case x >= gpu.Width:
+		x = gpu.Width - 1
+	}
+	switch {
+	case y < 0:
+		y = 0
+	case y >= gpu.Height:
+		y = gpu.Height - 1
+	}
+	return frame[y][x]
+}
+
+func (p *Pipeline) nearest(frame *gpu.Frame) {
+	for y := range frame {
+		for x, c := range frame[y] {
+			p.set(x, y, c)
+		}
+	}
+}
+
+// scale2x scales a frame up with the Scale2x algorithm, each pixel E becomes 2x2 pixels that take the color of the
+// neighbors B above, D left, F right or H below along edges between them
+func (p *Pipeline) scale2x(frame *gpu.Frame) {
+	for y := range frame {
+		for x, e := range frame[y] {
+			b, d, f, h := at(frame, x, y-1), at(frame, x-1, y), at(frame, x+1, y), at(frame, x, y+1)
+			e0, e1, e2, e3 := e, e, e, e
+			if b != h && d != f {
+				if d == b {
+					e0 = d
+				}
+				if b == f {
+					e1 = f
+				}
+				if d == h {
+					e2 = d
+				}
+				if h == f {
+					e3 = f
+				}
+			}
+			p.set(x*2, y*2, e0)
+			p.set(x*2+1, y*2, e1)
+			p.set(x*2, y*2+1, e2)
+			p.set(x*2+1, y*2+1, e3)
+		}
+	}
+}
+
+// scale3x scales a frame up with the Scale3x algorithm, each pixel E becomes 3x3 pixels using its 8 neighbors:
+//
+//	A B C
+//	D E F
+//	G H I
+func (p *Pipeline) scale3x(frame *gpu.Frame) {
+	for y := range frame {
+		for x, e := range frame[y] {
+			a, b, c := at(frame, x-1, y-1), at(frame, x, y-1), at(frame, x+1, y-1)
+			d, f := at(frame, x-1, y), at(frame, x+1, y)
+			g, h, i := at(frame, x-1, y+1), at(frame, x, y+1), at(frame, x+1, y+1)
+
+			out := [9]gpu.Color{e, e, e, e, e, e, e, e, e}
+			if b != h && d != f {
+				if d == b {
+					out[0] = d
+				}
+				if (d == b && e != c) || (b == f && e != a) {
+					out[1] = b
+				}
+				if b == f {
+					out[2] = f
+				}
+				if (d == b && e != g) || (d == h && e != a) {
+					out[3] = d
+				}
+				if (b == f && e != i) || (h == f && e != c) {
+					out[5] = f
+				}
+				if d == h {
+					out[6] = d
+				}
+				if (d == h && e != i) || (h == f && e != g) {
+					out[7] = h
+				}
+				if h == f {
+					out[8] = f
+				}
+			}
+			for n, c := range out {
+				p.set(x*3+n%3, y*3+n/3, c)
+			}
+		}
+	}
+}
+
+// lcd draws each pixel as a 3x3 dot, the right column and bottom row are darkened to form the grid between dots
+func (p *Pipeline) lcd(frame *gpu.Frame) {
+	for y := range frame {
+		for x, c := range frame[y] {
+			r, g, b := c.RGB5()
+			grid := gpu.RGB(r*3/4, g*3/4, b*3/4)
+			for dy := 0; dy < 3; dy++ {
+				for dx := 0; dx < 3; dx++ {
+					if dx == 2 || dy == 2 {
+						p.set(x*3+dx, y*3+dy, grid)
+					} else {
+						p.set(x*3+dx, y*3+dy, c)
+					}
+				}
+			}
+		}
+	}
+}
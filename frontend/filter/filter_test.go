@@ -0,0 +1,127 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+var (
+	white = gpu.RGB(31, 31, 31)
+	black = gpu.RGB(0, 0, 0)
+)
+
+// corner returns a white frame with black pixels above and left of the pixel at 1, 1, forming an edge that the
+// scaling filters smooth
+func corner() *gpu.Frame {
+	f := &gpu.Frame{}
+	for y := range f {
+		for x := range f[y] {
+			f[y][x] = white
+		}
+	}
+	f[0][1], f[1][0] = black, black
+	return f
+}
+
+// requirePixel asserts the color of a pixel of a filtered image
+func requirePixel(t *testing.T, expected gpu.Color, img *image.RGBA, x, y int) {
+	r, g, b := expected.RGB8()
+	require.Equal(t, color.RGBA{R: r, G: g, B: b, A: 0xff}, img.RGBAAt(x, y), "pixel %d, %d", x, y)
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Nearest",
+			test: func(t *testing.T) {
+				img := New(Nearest, false).Apply(corner())
+				require.Equal(t, image.Rect(0, 0, gpu.Width, gpu.Height), img.Bounds())
+				requirePixel(t, black, img, 1, 0)
+				requirePixel(t, white, img, 1, 1)
+			},
+		},
+		{
+			name: "Scale2x",
+			test: func(t *testing.T) {
+				img := New(Scale2x, false).Apply(corner())
+				require.Equal(t, image.Rect(0, 0, gpu.Width*2, gpu.Height*2), img.Bounds())
+				requirePixel(t, black, img, 2, 2)
+				requirePixel(t, white, img, 3, 2)
+				requirePixel(t, white, img, 2, 3)
+				requirePixel(t, white, img, 3, 3)
+				requirePixel(t, black, img, 2, 0)
+			},
+		},
+		{
+			name: "Scale3x",
+			test: func(t *testing.T) {
+				img := New(Scale3x, false).Apply(corner())
+				require.Equal(t, image.Rect(0, 0, gpu.Width*3, gpu.Height*3), img.Bounds())
+				requirePixel(t, black, img, 3, 3)
+				requirePixel(t, white, img, 4, 3)
+				requirePixel(t, white, img, 3, 4)
+				requirePixel(t, white, img, 5, 5)
+			},
+		},
+		{
+			name: "LCD",
+			test: func(t *testing.T) {
+				img := New(LCD, false).Apply(corner())
+				require.Equal(t, image.Rect(0, 0, gpu.Width*3, gpu.Height*3), img.Bounds())
+				requirePixel(t, white, img, 0, 0)
+				requirePixel(t, white, img, 1, 1)
+				requirePixel(t, gpu.RGB(23, 23, 23), img, 2, 0)
+				requirePixel(t, gpu.RGB(23, 23, 23), img, 0, 2)
+				requirePixel(t, black, img, 5, 0)
+			},
+		},
+		{
+			name: "Blend",
+			test: func(t *testing.T) {
+				p := New(Nearest, true)
+				requirePixel(t, black, p.Apply(corner()), 1, 0)
+
+				// The pixel flickers from black to white, and is white for good after that
+				f := corner()
+				f[0][1] = white
+				requirePixel(t, gpu.RGB(16, 16, 16), p.Apply(f), 1, 0)
+				requirePixel(t, white, p.Apply(f), 1, 0)
+			},
+		},
+		{
+			name: "Correction",
+			test: func(t *testing.T) {
+				p := New(Scale2x, false)
+				p.SetCorrection(gpu.CGBColors)
+				img := p.Apply(corner())
+				require.Equal(t, color.RGBA{R: 240, G: 240, B: 240, A: 0xff}, img.RGBAAt(3, 3))
+				requirePixel(t, black, img, 2, 2)
+			},
+		},
+		{
+			name: "Parse",
+			test: func(t *testing.T) {
+				f, err := ParseFilter("scale3x")
+				require.NoError(t, err)
+				require.Equal(t, Scale3x, f)
+				require.Equal(t, LCD, f.Next())
+				require.Equal(t, Nearest, LCD.Next())
+
+				_, err = ParseFilter("hq4x")
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
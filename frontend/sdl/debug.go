@@ -0,0 +1,134 @@
+package sdl
+
+import (
+	"image"
+	"image/draw"
+	"log"
+
+	sdl2 "github.com/veandco/go-sdl2/sdl"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// debugRefresh is the number of frames between updates of the debug windows, drawing them every frame would slow the
+// emulator down
+const debugRefresh = 6
+
+// debugView is a debug view of the GPU that can be shown in a window of its own.  Like the main window it copies
+// images to a streaming texture.
+type debugView struct {
+	title  string
+	key    sdl2.Keycode
+	render func(g *gpu.GPU) *image.RGBA
+
+	window   *sdl2.Window
+	renderer *sdl2.Renderer
+	texture  *sdl2.Texture
+	// size is the size of the texture, it is recreated when the size of the images changes
+	size image.Point
+	id   uint32
+}
+
+// newDebugViews returns the debug views: F1 shows the tiles, F2 both tile maps, F3 the sprites in OAM, F4 the palettes
+// and F5 the entries of OAM as a table
+func newDebugViews() []*debugView {
+	return []*debugView{
+		{title: "Tiles", key: sdl2.K_F1, render: func(g *gpu.GPU) *image.RGBA { return g.TileSheet() }},
+		{title: "Tile maps", key: sdl2.K_F2, render: tileMaps},
+		{title: "OAM", key: sdl2.K_F3, render: func(g *gpu.GPU) *image.RGBA { return g.OAMSheet() }},
+		{title: "Palettes", key: sdl2.K_F4, render: func(g *gpu.GPU) *image.RGBA { return g.PaletteSheet() }},
+		{title: "OAM table", key: sdl2.K_F5, render: func(g *gpu.GPU) *image.RGBA { return g.OAMTable() }},
+	}
+}
+
+// tileMaps returns the tile maps at 0x9800 and 0x9c00 side by side
+func tileMaps(g *gpu.GPU) *image.RGBA {
+	left, right := g.TileMap(0), g.TileMap(1)
+	img := image.NewRGBA(image.Rect(0, 0, left.Bounds().Dx()+right.Bounds().Dx(), left.Bounds().Dy()))
+	draw.Draw(img, left.Bounds(), left, image.Point{}, draw.Src)
+	draw.Draw(img, right.Bounds().Add(image.Pt(left.Bounds().Dx(), 0)), right, image.Point{}, draw.Src)
+	return img
+}
+
+// toggle opens the window of the view, or closes it if it is open
+func (v *debugView) toggle(g *gpu.GPU) error {
+	if v.window != nil {
+		v.close()
+		return nil
+	}
+
+	img := v.render(g)
+	window, err := sdl2.CreateWindow(
+		v.title,
+		sdl2.WINDOWPOS_UNDEFINED,
+		sdl2.WINDOWPOS_UNDEFINED,
+		int32(img.Bounds().Dx()),
+		int32(img.Bounds().Dy()),
+		sdl2.WINDOW_SHOWN,
+	)
+	if err != nil {
+		return err
+	}
+	v.window = window
+	if v.id, err = window.GetID(); err != nil {
+		v.close()
+		return err
+	}
+	if v.renderer, err = sdl2.CreateRenderer(window, -1, sdl2.RENDERER_ACCELERATED); err != nil {
+		v.close()
+		return err
+	}
+
+	if err := v.draw(img); err != nil {
+		v.close()
+		return err
+	}
+	return nil
+}
+
+// update redraws the view if its window is open
+func (v *debugView) update(g *gpu.GPU) {
+	if v.window != nil {
+		if err := v.draw(v.render(g)); err != nil {
+			log.Printf("Failed to draw the %s window: %s", v.title, err)
+		}
+	}
+}
+
+// draw copies an image to the texture and shows it in the window
+func (v *debugView) draw(img *image.RGBA) error {
+	if size := img.Bounds().Size(); v.texture == nil || size != v.size {
+		if v.texture != nil {
+			v.texture.Destroy()
+			v.texture = nil
+		}
+		texture, err := v.renderer.CreateTexture(sdl2.PIXELFORMAT_RGBA32, sdl2.TEXTUREACCESS_STREAMING,
+			int32(size.X), int32(size.Y))
+		if err != nil {
+			return err
+		}
+		v.texture, v.size = texture, size
+	}
+
+	if err := v.texture.Update(nil, img.Pix, img.Stride); err != nil {
+		return err
+	}
+	v.renderer.Clear()
+	v.renderer.Copy(v.texture, nil, nil)
+	v.renderer.Present()
+	return nil
+}
+
+// close closes the window of the view
+func (v *debugView) close() {
+	if v.texture != nil {
+		v.texture.Destroy()
+	}
+	if v.renderer != nil {
+		v.renderer.Destroy()
+	}
+	if v.window != nil {
+		v.window.Destroy()
+	}
+	v.window, v.renderer, v.texture, v.size, v.id = nil, nil, nil, image.Point{}, 0
+}
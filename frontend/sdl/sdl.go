@@ -0,0 +1,363 @@
+// Package sdl shows the emulator in an SDL window and reads the buttons from the keyboard
+package sdl
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	sdl2 "github.com/veandco/go-sdl2/sdl"
+
+	"github.com/borgstrom/ebgb/emulator"
+	"github.com/borgstrom/ebgb/frontend/filter"
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// keys maps the keyboard to the buttons
+var keys = map[sdl2.Keycode]emulator.Buttons{
+	sdl2.K_RIGHT:     emulator.ButtonRight,
+	sdl2.K_LEFT:      emulator.ButtonLeft,
+	sdl2.K_UP:        emulator.ButtonUp,
+	sdl2.K_DOWN:      emulator.ButtonDown,
+	sdl2.K_x:         emulator.ButtonA,
+	sdl2.K_z:         emulator.ButtonB,
+	sdl2.K_BACKSPACE: emulator.ButtonSelect,
+	sdl2.K_RETURN:    emulator.ButtonStart,
+}
+
+// layerKeys and overlayKeys map the number keys to the layers and overlays they toggle
+var (
+	layerKeys = map[sdl2.Keycode]gpu.Layers{
+		sdl2.K_1: gpu.LayerBackground,
+		sdl2.K_2: gpu.LayerWindow,
+		sdl2.K_3: gpu.LayerSprites,
+	}
+	overlayKeys = map[sdl2.Keycode]gpu.Overlays{
+		sdl2.K_4: gpu.OverlaySpriteBoxes,
+		sdl2.K_5: gpu.OverlayWindowOrigin,
+		sdl2.K_6: gpu.OverlayLayerTint,
+	}
+)
+
+// defaultScale is the size of the window as a multiple of the screen when Options.Scale is not set
+const defaultScale = 3
+
+// Options control how frames are shown in the window
+type Options struct {
+	// Scale is the size the window opens at as a multiple of 160x144, the window can be resized afterwards
+	Scale int
+	// Fullscreen starts in fullscreen at the resolution of the desktop
+	Fullscreen bool
+	// Filter scales frames up before they are shown, Blend blends each frame with the one before it
+	Filter filter.Filter
+	Blend  bool
+	// Correction is the color correction applied to the frames that are shown, CorrectScreenshots applies it to
+	// screenshots too and keeps them in step when it is changed
+	Correction         gpu.Correction
+	CorrectScreenshots bool
+}
+
+// Frontend is a window that implements emulator.VideoSink and emulator.InputSource.  Frames are scaled up by whole
+// numbers only, with black bars around them to keep their aspect ratio.
+type Frontend struct {
+	window   *sdl2.Window
+	renderer *sdl2.Renderer
+	// texture holds the last frame after it went through the pipeline
+	texture  *sdl2.Texture
+	pipeline *filter.Pipeline
+
+	// id identifies the window in window events
+	id uint32
+
+	buttons emulator.Buttons
+	// hotkey is called for keys that aren't buttons
+	hotkey func(key sdl2.Keycode)
+	quit   bool
+
+	// gpu returns the GPU shown in the debug views, which are updated every debugRefresh frames.  It is looked up on
+	// every update as a hard reset of the emulator replaces the GPU.
+	gpu    func() *gpu.GPU
+	views  []*debugView
+	frames int
+}
+
+// New initializes SDL and opens a window, it must be called from the main thread
+func New(title string, options Options) (*Frontend, error) {
+	if options.Scale < 1 {
+		options.Scale = defaultScale
+	}
+
+	if err := sdl2.Init(sdl2.INIT_EVERYTHING); err != nil {
+		return nil, err
+	}
+	// SDL scales with the nearest pixel, smoothing is left to the filters
+	sdl2.SetHint(sdl2.HINT_RENDER_SCALE_QUALITY, "0")
+
+	window, err := sdl2.CreateWindow(
+		title,
+		sdl2.WINDOWPOS_UNDEFINED,
+		sdl2.WINDOWPOS_UNDEFINED,
+		int32(gpu.Width*options.Scale),
+		int32(gpu.Height*options.Scale),
+		sdl2.WINDOW_SHOWN|sdl2.WINDOW_RESIZABLE,
+	)
+	if err != nil {
+		sdl2.Quit()
+		return nil, err
+	}
+
+	f := &Frontend{window: window, pipeline: filter.New(options.Filter, options.Blend), views: newDebugViews()}
+	f.pipeline.SetCorrection(options.Correction)
+	if err := f.init(options); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// init sets up rendering to the window.  The logical size of the renderer is the size of the screen, with integer
+// scaling SDL then letterboxes it within the window.
+func (f *Frontend) init(options Options) error {
+	var err error
+	if f.id, err = f.window.GetID(); err != nil {
+		return err
+	}
+	if f.renderer, err = sdl2.CreateRenderer(f.window, -1, sdl2.RENDERER_ACCELERATED); err != nil {
+		return err
+	}
+	if err := f.renderer.SetLogicalSize(gpu.Width, gpu.Height); err != nil {
+		return err
+	}
+	if err := f.renderer.SetIntegerScale(true); err != nil {
+		return err
+	}
+	if err := f.createTexture(); err != nil {
+		return err
+	}
+	if options.Fullscreen {
+		return f.window.SetFullscreen(sdl2.WINDOW_FULLSCREEN_DESKTOP)
+	}
+	return nil
+}
+
+// createTexture creates the texture the frames are copied to, at the size of the images of the filter
+func (f *Frontend) createTexture() error {
+	if f.texture != nil {
+		f.texture.Destroy()
+		f.texture = nil
+	}
+
+	scale := f.pipeline.Filter().Scale()
+	texture, err := f.renderer.CreateTexture(sdl2.PIXELFORMAT_RGBA32, sdl2.TEXTUREACCESS_STREAMING,
+		int32(gpu.Width*scale), int32(gpu.Height*scale))
+	if err != nil {
+		return err
+	}
+	f.texture = texture
+	return nil
+}
+
+// Close closes the windows and shuts down SDL
+func (f *Frontend) Close() {
+	for _, v := range f.views {
+		v.close()
+	}
+	if f.texture != nil {
+		f.texture.Destroy()
+	}
+	if f.renderer != nil {
+		f.renderer.Destroy()
+	}
+	f.window.Destroy()
+	sdl2.Quit()
+}
+
+// Frame implements emulator.VideoSink by drawing the frame in the window
+func (f *Frontend) Frame(frame *gpu.Frame) {
+	img := f.pipeline.Apply(frame)
+	f.texture.Update(nil, img.Pix, img.Stride)
+	f.renderer.SetDrawColor(0, 0, 0, 0xff)
+	f.renderer.Clear()
+	f.renderer.Copy(f.texture, nil, nil)
+	f.renderer.Present()
+
+	f.frames++
+	if f.gpu != nil && f.frames%debugRefresh == 0 {
+		for _, v := range f.views {
+			v.update(f.gpu())
+		}
+	}
+}
+
+// toggleFullscreen switches between the window and fullscreen at the resolution of the desktop
+func (f *Frontend) toggleFullscreen() {
+	flags := uint32(sdl2.WINDOW_FULLSCREEN_DESKTOP)
+	if f.window.GetFlags()&sdl2.WINDOW_FULLSCREEN_DESKTOP == sdl2.WINDOW_FULLSCREEN_DESKTOP {
+		flags = 0
+	}
+	if err := f.window.SetFullscreen(flags); err != nil {
+		log.Printf("Failed to switch fullscreen: %s", err)
+	}
+}
+
+// nextFilter switches to the next filter
+func (f *Frontend) nextFilter() {
+	f.pipeline.SetFilter(f.pipeline.Filter().Next())
+	if err := f.createTexture(); err != nil {
+		log.Printf("Failed to switch filter: %s", err)
+		f.pipeline.SetFilter(filter.Nearest)
+		f.createTexture()
+		return
+	}
+	log.Printf("Filter: %s", f.pipeline.Filter())
+}
+
+// nextCorrection switches to the next color correction
+func (f *Frontend) nextCorrection() {
+	c := (f.pipeline.Correction() + 1) % (gpu.AGBColors + 1)
+	f.pipeline.SetCorrection(c)
+	log.Printf("Color correction: %s", c)
+}
+
+// toggleBlend turns frame blending on or off
+func (f *Frontend) toggleBlend() {
+	f.pipeline.SetBlend(!f.pipeline.Blend())
+	log.Printf("Frame blending: %t", f.pipeline.Blend())
+}
+
+// toggleView opens or closes the debug view for a key, it returns false if no view uses the key
+func (f *Frontend) toggleView(key sdl2.Keycode) bool {
+	for _, v := range f.views {
+		if v.key != key {
+			continue
+		}
+		if f.gpu != nil {
+			if err := v.toggle(f.gpu()); err != nil {
+				log.Printf("Failed to open the %s window: %s", v.title, err)
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Buttons implements emulator.InputSource
+func (f *Frontend) Buttons() emulator.Buttons {
+	return f.buttons
+}
+
+// poll handles the pending SDL events
+func (f *Frontend) poll() {
+	for event := sdl2.PollEvent(); event != nil; event = sdl2.PollEvent() {
+		switch ev := event.(type) {
+		case *sdl2.QuitEvent:
+			f.quit = true
+
+		case *sdl2.WindowEvent:
+			// With debug windows open closing the main window doesn't quit SDL, closing a debug window only closes it
+			if ev.Event != sdl2.WINDOWEVENT_CLOSE {
+				continue
+			}
+			if ev.WindowID == f.id {
+				f.quit = true
+			}
+			for _, v := range f.views {
+				if v.window != nil && ev.WindowID == v.id {
+					v.close()
+				}
+			}
+
+		case *sdl2.KeyboardEvent:
+			button, ok := keys[ev.Keysym.Sym]
+			switch {
+			case ok && ev.Type == sdl2.KEYDOWN:
+				f.buttons |= button
+			case ok && ev.Type == sdl2.KEYUP:
+				f.buttons &^= button
+			case ev.Type == sdl2.KEYDOWN && f.hotkey != nil:
+				f.hotkey(ev.Keysym.Sym)
+			}
+		}
+	}
+}
+
+// Run shows the emulator in a new window until it is closed or the context is cancelled, it must be called from the
+// main thread.  P switches to the next palette and F12 saves a screenshot next to the ROM.  F1 - F5 open the debug
+// views and F10 saves them next to the ROM.  1 - 3 hide the background, window and sprites, and 4 - 6 toggle the
+// sprite box, window origin and layer tint overlays.  F11 switches to fullscreen, F cycles through the filters, B
+// toggles frame blending and C cycles through the color corrections, which screenshots follow if CorrectScreenshots
+// is set.
+func Run(ctx context.Context, e *emulator.Emulator, options Options) error {
+	f, err := New(e.Title(), options)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	f.gpu = e.GPU
+	if options.CorrectScreenshots {
+		e.SetScreenshotCorrection(options.Correction)
+	}
+	f.hotkey = func(key sdl2.Keycode) {
+		if f.toggleView(key) {
+			return
+		}
+
+		if l, ok := layerKeys[key]; ok {
+			e.ToggleLayer(l)
+			return
+		}
+		if o, ok := overlayKeys[key]; ok {
+			e.ToggleOverlay(o)
+			return
+		}
+
+		switch key {
+		case sdl2.K_p:
+			e.NextPalette()
+		case sdl2.K_F11:
+			f.toggleFullscreen()
+		case sdl2.K_f:
+			f.nextFilter()
+		case sdl2.K_b:
+			f.toggleBlend()
+		case sdl2.K_c:
+			f.nextCorrection()
+			if options.CorrectScreenshots {
+				e.SetScreenshotCorrection(f.pipeline.Correction())
+			}
+		case sdl2.K_F12:
+			path, err := e.TakeScreenshot()
+			if err != nil {
+				log.Printf("Failed to save screenshot: %s", err)
+				return
+			}
+			log.Printf("Saved screenshot %s", path)
+		case sdl2.K_F10:
+			paths, err := e.SaveDebugViews()
+			if err != nil {
+				log.Printf("Failed to save debug views: %s", err)
+				return
+			}
+			log.Printf("Saved debug views %s", strings.Join(paths, ", "))
+		}
+	}
+
+	e.SetVideoSink(f)
+	e.SetInputSource(f)
+	defer e.SetVideoSink(nil)
+	defer e.SetInputSource(nil)
+
+	f.Frame(e.Frame())
+	for !f.quit {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		f.poll()
+		e.RunFrame()
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package gpu
+
+// Color is a 15 bit RGB color in the format of CGB palette RAM, red is in bits 0 - 4, green in bits 5 - 9 and blue in
+// bits 10 - 14
+type Color uint16
+
+// RGB returns the Color with the given 5 bit components
+func RGB(r, g, b uint8) Color {
+	return Color(r&0x1f) | Color(g&0x1f)<<5 | Color(b&0x1f)<<10
+}
+
+// RGB5 returns the 5 bit components of the color
+func (c Color) RGB5() (r, g, b uint8) {
+	return uint8(c & 0x1f), uint8(c >> 5 & 0x1f), uint8(c >> 10 & 0x1f)
+}
+
+// RGB8 returns the components of the color scaled to 8 bits
+func (c Color) RGB8() (r, g, b uint8) {
+	r, g, b = c.RGB5()
+	return expand5(r), expand5(g), expand5(b)
+}
+
+// RGBA implements color.Color
+func (c Color) RGBA() (r, g, b, a uint32) {
+	r8, g8, b8 := c.RGB8()
+	return uint32(r8) * 0x101, uint32(g8) * 0x101, uint32(b8) * 0x101, 0xffff
+}
+
+// expand5 scales a 5 bit component to 8 bits, repeating the high bits in the low bits so that 0x1f becomes 0xff
+func expand5(v uint8) uint8 {
+	return v<<3 | v>>2
+}
+
+// grays are the colors used for the four DMG shades until SetDMGColors is called
+var grays = [4]Color{RGB(31, 31, 31), RGB(21, 21, 21), RGB(10, 10, 10), RGB(0, 0, 0)}
+
+// Layers of the DMG, each has its own palette register and can be given different colors
+const (
+	layerBG = iota
+	layerOBJ0
+	layerOBJ1
+)
+
+// SetDMGColors sets the colors of the four shades, from lightest to darkest, for the background and window and both
+// sprite palettes.  They are used when not running in CGB mode.
+func (g *GPU) SetDMGColors(bg, obj0, obj1 [4]Color) {
+	g.dmgColors = [3][4]Color{bg, obj0, obj1}
+}
+
+// paletteColor returns a color from CGB palette RAM, each of the 8 palettes holds 4 little endian colors
+func paletteColor(ram *[64]uint8, palette, color uint8) Color {
+	i := int(palette&0x07)*8 + int(color)*2
+	return Color(uint16(ram[i])|uint16(ram[i+1])<<8) & 0x7fff
+}
@@ -0,0 +1,106 @@
+package gpu
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Correction is a curve that converts colors to sRGB the way an LCD shows them.  Colors are used as they are by the
+// GPU, correction is applied when frames are shown or saved.
+type Correction int
+
+const (
+	// RawColors scales the 5 bit components to 8 bits without any correction, it is what palettes are designed with
+	RawColors Correction = iota
+	// CGBColors follows the CGB LCD, which mixes the channels and is darker than a monitor
+	CGBColors
+	// AGBColors follows the darker, lower contrast LCD of the AGB
+	AGBColors
+)
+
+var correctionNames = map[Correction]string{
+	RawColors: "raw",
+	CGBColors: "cgb",
+	AGBColors: "agb",
+}
+
+func (c Correction) String() string {
+	if name, ok := correctionNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("Correction(%d)", int(c))
+}
+
+// ParseCorrection returns the Correction for a name such as "raw" or "cgb"
+func ParseCorrection(name string) (Correction, error) {
+	for c, n := range correctionNames {
+		if n == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown color correction %q", name)
+}
+
+// correctionTable holds the corrected 8 bit components of every Color
+type correctionTable [0x8000][3]uint8
+
+var (
+	correctionOnce   [3]sync.Once
+	correctionTables [3]*correctionTable
+)
+
+// RGB8 returns the components of a color scaled to 8 bits and corrected by the curve
+func (c Correction) RGB8(color Color) (r, g, b uint8) {
+	if c != CGBColors && c != AGBColors {
+		return color.RGB8()
+	}
+
+	correctionOnce[c].Do(func() {
+		t := &correctionTable{}
+		for i := range t {
+			r, g, b := Color(i).RGB5()
+			if c == CGBColors {
+				t[i] = cgbColor(r, g, b)
+			} else {
+				t[i] = agbColor(r, g, b)
+			}
+		}
+		correctionTables[c] = t
+	})
+	rgb := correctionTables[c][color&0x7fff]
+	return rgb[0], rgb[1], rgb[2]
+}
+
+// cgbColor mixes the channels as the CGB LCD does, red and green bleed into blue and white comes out at 240
+func cgbColor(r, g, b uint8) [3]uint8 {
+	mix := func(v int) uint8 {
+		if v > 960 {
+			v = 960
+		}
+		return uint8(v >> 2)
+	}
+	return [3]uint8{
+		mix(int(r)*26 + int(g)*4 + int(b)*2),
+		mix(int(g)*24 + int(b)*8),
+		mix(int(r)*6 + int(g)*4 + int(b)*22),
+	}
+}
+
+// agbColor applies the gamma of the AGB LCD, mixes the channels and converts them back to the gamma of a monitor
+func agbColor(r, g, b uint8) [3]uint8 {
+	const lcdGamma, outGamma = 4.0, 2.2
+	lr := math.Pow(float64(r)/31, lcdGamma)
+	lg := math.Pow(float64(g)/31, lcdGamma)
+	lb := math.Pow(float64(b)/31, lcdGamma)
+
+	out := func(v float64) uint8 {
+		v = math.Pow(v/255, 1/outGamma) * 255 * 255 / 280
+		return uint8(math.Min(255, math.Round(v)))
+	}
+	return [3]uint8{
+		out(0*lb + 50*lg + 255*lr),
+		out(30*lb + 230*lg + 10*lr),
+		out(220*lb + 10*lg + 50*lr),
+	}
+}
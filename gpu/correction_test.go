@@ -0,0 +1,69 @@
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrection(t *testing.T) {
+	rgb := func(c Correction, color Color) [3]uint8 {
+		r, g, b := c.RGB8(color)
+		return [3]uint8{r, g, b}
+	}
+
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Raw",
+			test: func(t *testing.T) {
+				require.Equal(t, [3]uint8{0xff, 0x00, 0x84}, rgb(RawColors, RGB(31, 0, 16)))
+			},
+		},
+		{
+			name: "CGB",
+			test: func(t *testing.T) {
+				require.Equal(t, [3]uint8{0, 0, 0}, rgb(CGBColors, RGB(0, 0, 0)))
+				require.Equal(t, [3]uint8{240, 240, 240}, rgb(CGBColors, RGB(31, 31, 31)))
+				// Red bleeds into blue
+				require.Equal(t, [3]uint8{201, 0, 46}, rgb(CGBColors, RGB(31, 0, 0)))
+			},
+		},
+		{
+			name: "AGB",
+			test: func(t *testing.T) {
+				require.Equal(t, [3]uint8{0, 0, 0}, rgb(AGBColors, RGB(0, 0, 0)))
+				// Green bleeds into red and blue, and the LCD can't show pure green
+				c := rgb(AGBColors, RGB(0, 31, 0))
+				require.NotZero(t, c[0])
+				require.NotZero(t, c[2])
+				require.Less(t, c[1], uint8(0xff))
+			},
+		},
+		{
+			name: "Image",
+			test: func(t *testing.T) {
+				f := &Frame{}
+				f[0][0] = RGB(31, 31, 31)
+				require.EqualValues(t, 0xff, f.Image(1).Pix[0])
+				require.EqualValues(t, 240, f.CorrectedImage(1, CGBColors).Pix[0])
+			},
+		},
+		{
+			name: "Parse",
+			test: func(t *testing.T) {
+				c, err := ParseCorrection("agb")
+				require.NoError(t, err)
+				require.Equal(t, AGBColors, c)
+				_, err = ParseCorrection("srgb")
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
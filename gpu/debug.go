@@ -0,0 +1,262 @@
+package gpu
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// The debug views below are rendered straight from VRAM, OAM and palette RAM with the current palettes, to help track
+// down graphics corruption.  They don't depend on what has been drawn to the screen.
+
+const (
+	// tileCount is the number of tiles in a VRAM bank, tileSheetColumns the number of tiles in a row of the tile sheet
+	tileCount        = 384
+	tileSheetColumns = 16
+
+	// oamSheetColumns is the number of sprites in a row of the OAM sheet
+	oamSheetColumns = 8
+
+	// oamTableRows is the number of entries in a column of the OAM table, oamTableWidth the width of a column
+	oamTableRows  = 20
+	oamTableWidth = 120
+
+	// swatchSize is the size of a color in the palette sheet
+	swatchSize = 16
+)
+
+var (
+	// textColor and paperColor are the colors of the text in the OAM table and its background
+	textColor  = RGB(0, 0, 0)
+	paperColor = RGB(31, 31, 31)
+	// viewportColor outlines the visible part of a tile map
+	viewportColor = RGB(31, 0, 0)
+	// backdropColor shows through transparent sprite pixels and unused palettes
+	backdropColor = RGB(31, 0, 31)
+)
+
+// TileSheet returns the 384 tiles of VRAM bank 0 in rows of 16, followed on the right by the tiles of bank 1 in CGB
+// mode.  The tiles are drawn with BGP, or with background palette 0 in CGB mode.
+func (g *GPU) TileSheet() *image.RGBA {
+	banks := 1
+	if g.color {
+		banks = 2
+	}
+	img := image.NewRGBA(image.Rect(0, 0, banks*tileSheetColumns*8, tileCount/tileSheetColumns*8))
+
+	for bank := 0; bank < banks; bank++ {
+		for tile := 0; tile < tileCount; tile++ {
+			address := uint16(0x8000 + bank*0x2000 + tile*16)
+			left, top := (bank*tileSheetColumns+tile%tileSheetColumns)*8, tile/tileSheetColumns*8
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					c := g.bgColor(bgPixel{color: g.tilePixel(address, uint8(x), uint8(y))})
+					setPixel(img, left+x, top+y, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// TileMap returns the 256x256 pixel background tile map at 0x9800 for map 0 or at 0x9c00 for map 1, with the part of
+// the background that SCX and SCY make visible outlined.  The tiles are addressed as selected by LCDC.
+func (g *GPU) TileMap(n int) *image.RGBA {
+	base := uint16(0x9800)
+	if n != 0 {
+		base = 0x9c00
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			setPixel(img, x, y, g.bgColor(g.mapPixel(base, uint8(x), uint8(y))))
+		}
+	}
+
+	// The viewport wraps around the edges of the map
+	for x := 0; x < Width; x++ {
+		setPixel(img, int(g.scx+uint8(x)), int(g.scy), viewportColor)
+		setPixel(img, int(g.scx+uint8(x)), int(g.scy+Height-1), viewportColor)
+	}
+	for y := 0; y < Height; y++ {
+		setPixel(img, int(g.scx), int(g.scy+uint8(y)), viewportColor)
+		setPixel(img, int(g.scx+Width-1), int(g.scy+uint8(y)), viewportColor)
+	}
+	return img
+}
+
+// Sprite is a decoded OAM entry
+type Sprite struct {
+	// Index is the position of the entry in OAM
+	Index int
+	// X and Y are the position of the top left corner of the sprite on the screen, OAM stores them offset by 8 and 16
+	X, Y       int
+	Tile       uint8
+	Attributes uint8
+
+	// Behind is set when the sprite is drawn behind background colors 1 - 3
+	Behind       bool
+	FlipX, FlipY bool
+	// Palette is 0 for OBP0 or 1 for OBP1 outside of CGB mode, and one of the 8 OBJ palettes in it
+	Palette int
+	// Bank is the VRAM bank of the tile, it is always 0 outside of CGB mode
+	Bank int
+}
+
+func (s Sprite) String() string {
+	flip := []byte("--")
+	if s.FlipX {
+		flip[0] = 'x'
+	}
+	if s.FlipY {
+		flip[1] = 'y'
+	}
+	priority := "above"
+	if s.Behind {
+		priority = "behind"
+	}
+	return fmt.Sprintf("%2d  x=%4d y=%4d  tile=%02x bank=%d  attr=%02x palette=%d flip=%s %s", s.Index, s.X, s.Y, s.Tile,
+		s.Bank, s.Attributes, s.Palette, flip, priority)
+}
+
+// OAM returns the 40 OAM entries with their attributes decoded
+func (g *GPU) OAM() []Sprite {
+	sprites := make([]Sprite, 0, len(g.oam)/4)
+	for i := 0; i < len(g.oam); i += 4 {
+		attributes := g.oam[i+3]
+		s := Sprite{
+			Index:      i / 4,
+			Y:          int(g.oam[i]) - 16,
+			X:          int(g.oam[i+1]) - 8,
+			Tile:       g.oam[i+2],
+			Attributes: attributes,
+			Behind:     attributes&attrPriority != 0,
+			FlipX:      attributes&attrXFlip != 0,
+			FlipY:      attributes&attrYFlip != 0,
+		}
+		if g.color {
+			s.Palette = int(attributes & attrCGBPalette)
+			if attributes&attrBank != 0 {
+				s.Bank = 1
+			}
+		} else if attributes&attrPalette != 0 {
+			s.Palette = 1
+		}
+		sprites = append(sprites, s)
+	}
+	return sprites
+}
+
+// OAMSheet returns the 40 sprites in OAM order in rows of 8, each drawn with its palette and flips in a cell of 8x16
+// pixels.  Transparent pixels show the backdrop color.
+func (g *GPU) OAMSheet() *image.RGBA {
+	count := len(g.oam) / 4
+	img := image.NewRGBA(image.Rect(0, 0, oamSheetColumns*8, (count+oamSheetColumns-1)/oamSheetColumns*16))
+	fill(img, img.Bounds(), backdropColor)
+
+	height := g.spriteHeight()
+	for i := 0; i < count; i++ {
+		s := sprite{index: i, tile: g.oam[i*4+2], attributes: g.oam[i*4+3]}
+		left, top := i%oamSheetColumns*8, i/oamSheetColumns*16
+		for y := 0; y < height; y++ {
+			for x := 0; x < 8; x++ {
+				if c := g.spriteTilePixel(s, height, x, y); c != 0 {
+					setPixel(img, left+x, top+y, g.objColor(objPixel{color: c, attributes: s.attributes}))
+				}
+			}
+		}
+	}
+	return img
+}
+
+// OAMTable returns the 40 OAM entries as a table in two columns.  Each row holds the index of the entry, the sprite and
+// the four bytes of the entry as stored in OAM: Y, X, tile and attributes.  All numbers are hexadecimal.
+func (g *GPU) OAMTable() *image.RGBA {
+	count := len(g.oam) / 4
+	img := image.NewRGBA(image.Rect(0, 0, 2*oamTableWidth, oamTableRows*16))
+	fill(img, img.Bounds(), paperColor)
+
+	height := g.spriteHeight()
+	for i := 0; i < count; i++ {
+		left, top := i/oamTableRows*oamTableWidth, i%oamTableRows*16
+		drawHex(img, left, top+3, uint8(i))
+
+		s := sprite{index: i, tile: g.oam[i*4+2], attributes: g.oam[i*4+3]}
+		fill(img, image.Rect(left+20, top, left+28, top+16), backdropColor)
+		for y := 0; y < height; y++ {
+			for x := 0; x < 8; x++ {
+				if c := g.spriteTilePixel(s, height, x, y); c != 0 {
+					setPixel(img, left+20+x, top+y, g.objColor(objPixel{color: c, attributes: s.attributes}))
+				}
+			}
+		}
+
+		for field := 0; field < 4; field++ {
+			drawHex(img, left+32+field*20, top+3, g.oam[i*4+field])
+		}
+	}
+	return img
+}
+
+// hexGlyphs are 3x5 pixel glyphs of the hexadecimal digits, the top bit of each row is the leftmost pixel
+var hexGlyphs = [16][5]uint8{
+	{7, 5, 5, 5, 7}, {2, 6, 2, 2, 7}, {7, 1, 7, 4, 7}, {7, 1, 7, 1, 7},
+	{5, 5, 7, 1, 1}, {7, 4, 7, 1, 7}, {7, 4, 7, 5, 7}, {7, 1, 1, 1, 1},
+	{7, 5, 7, 5, 7}, {7, 5, 7, 1, 7}, {7, 5, 7, 5, 5}, {6, 5, 6, 5, 6},
+	{7, 4, 4, 4, 7}, {6, 5, 5, 5, 6}, {7, 4, 7, 4, 7}, {7, 4, 7, 4, 4},
+}
+
+// drawHex draws a byte as two hexadecimal digits with the glyphs doubled in size, the digits take up 14x10 pixels
+func drawHex(img *image.RGBA, left, top int, v uint8) {
+	for i, digit := range []uint8{v >> 4, v & 0x0f} {
+		for y, row := range hexGlyphs[digit] {
+			for x := 0; x < 3; x++ {
+				if row&(4>>x) != 0 {
+					x0, y0 := left+i*8+x*2, top+y*2
+					fill(img, image.Rect(x0, y0, x0+2, y0+2), textColor)
+				}
+			}
+		}
+	}
+}
+
+// PaletteSheet returns the palettes as rows of four swatches, the background palettes on the left and the sprite
+// palettes on the right.  In CGB mode there are 8 of each from palette RAM, otherwise the rows show BGP, and OBP0 and
+// OBP1.
+func (g *GPU) PaletteSheet() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 8*swatchSize, 8*swatchSize))
+	fill(img, img.Bounds(), backdropColor)
+
+	swatch := func(column, row int, c Color) {
+		fill(img, image.Rect(column*swatchSize, row*swatchSize, (column+1)*swatchSize, (row+1)*swatchSize), c)
+	}
+	for c := uint8(0); c < 4; c++ {
+		if !g.color {
+			swatch(int(c), 0, g.bgColor(bgPixel{color: c}))
+			swatch(4+int(c), 0, g.objColor(objPixel{color: c}))
+			swatch(4+int(c), 1, g.objColor(objPixel{color: c, attributes: attrPalette}))
+			continue
+		}
+		for palette := uint8(0); palette < 8; palette++ {
+			swatch(int(c), int(palette), g.bgColor(bgPixel{color: c, attributes: palette}))
+			swatch(4+int(c), int(palette), g.objColor(objPixel{color: c, attributes: palette}))
+		}
+	}
+	return img
+}
+
+// setPixel sets a pixel of an image to a Color
+func setPixel(img *image.RGBA, x, y int, c Color) {
+	r, g, b := c.RGB8()
+	img.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: 0xff})
+}
+
+// fill sets a rectangle of an image to a Color
+func fill(img *image.RGBA, rect image.Rectangle, c Color) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			setPixel(img, x, y, c)
+		}
+	}
+}
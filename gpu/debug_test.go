@@ -0,0 +1,156 @@
+package gpu
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// requirePixel asserts the color of a pixel of a debug view
+func requirePixel(t *testing.T, expected Color, img *image.RGBA, x, y int) {
+	r, g, b := expected.RGB8()
+	require.Equal(t, color.RGBA{R: r, G: g, B: b, A: 0xff}, img.RGBAAt(x, y), "pixel %d, %d", x, y)
+}
+
+func TestDebug(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T, g *GPU)
+	}{
+		{
+			name: "TileSheet",
+			test: func(t *testing.T, g *GPU) {
+				// Tile 17 has color 3 in its top left pixel, it is the second tile of the second row
+				g.Write(0x8110, 0x80)
+				g.Write(0x8111, 0x80)
+
+				img := g.TileSheet()
+				require.Equal(t, image.Rect(0, 0, 128, 192), img.Bounds())
+				requirePixel(t, grays[3], img, 8, 8)
+				requirePixel(t, grays[0], img, 9, 8)
+			},
+		},
+		{
+			name: "TileMap",
+			test: func(t *testing.T, g *GPU) {
+				// Tile 1 is solid color 1 and fills map 1, scrolled so that the viewport wraps
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8010+i, 0xff)
+				}
+				for i := uint16(0); i < 32*32; i++ {
+					g.Write(0x9c00+i, 0x01)
+				}
+				g.Write(0xff42, 200)
+				g.Write(0xff43, 100)
+				g.Write(0xff40, lcdcTileData)
+
+				img := g.TileMap(1)
+				require.Equal(t, image.Rect(0, 0, 256, 256), img.Bounds())
+				requirePixel(t, grays[1], img, 50, 50)
+				requirePixel(t, viewportColor, img, 100, 200)
+				requirePixel(t, viewportColor, img, 259-256, 200)
+				requirePixel(t, viewportColor, img, 100, 343-256)
+				requirePixel(t, grays[1], img, 101, 201)
+
+				requirePixel(t, grays[0], g.TileMap(0), 50, 50)
+			},
+		},
+		{
+			name: "OAM",
+			test: func(t *testing.T, g *GPU) {
+				g.Write(0xfe04, 20)
+				g.Write(0xfe05, 10)
+				g.Write(0xfe06, 0x42)
+				g.Write(0xfe07, attrPriority|attrXFlip|attrPalette)
+
+				sprites := g.OAM()
+				require.Len(t, sprites, 40)
+				require.Equal(t, Sprite{
+					Index: 1, X: 2, Y: 4, Tile: 0x42, Attributes: 0xb0,
+					Behind: true, FlipX: true, Palette: 1,
+				}, sprites[1])
+				require.Equal(t, " 1  x=   2 y=   4  tile=42 bank=0  attr=b0 palette=1 flip=x- behind", sprites[1].String())
+			},
+		},
+		{
+			name: "OAMSheet",
+			test: func(t *testing.T, g *GPU) {
+				// Sprite 9 uses tile 1, which has color 2 in its top left pixel, and is flipped horizontally
+				g.Write(0x8011, 0x80)
+				g.Write(0xfe26, 0x01)
+				g.Write(0xfe27, attrXFlip)
+
+				img := g.OAMSheet()
+				require.Equal(t, image.Rect(0, 0, 64, 80), img.Bounds())
+				requirePixel(t, grays[2], img, 15, 16)
+				requirePixel(t, backdropColor, img, 8, 16)
+			},
+		},
+		{
+			name: "OAMTable",
+			test: func(t *testing.T, g *GPU) {
+				// Sprite 9 uses tile 1 as in OAMSheet, sprite 20 starts the second column
+				g.Write(0x8011, 0x80)
+				g.Write(0xfe26, 0x01)
+				g.Write(0xfe27, attrXFlip)
+				g.Write(0xfe50, 0x10)
+
+				img := g.OAMTable()
+				require.Equal(t, image.Rect(0, 0, 240, 320), img.Bounds())
+				requirePixel(t, grays[2], img, 27, 144)
+				requirePixel(t, backdropColor, img, 20, 144)
+
+				// The index and the tile are written in hexadecimal, the top row of a 1 only has its middle pixel set
+				requirePixel(t, textColor, img, 0, 147)
+				requirePixel(t, textColor, img, 72, 147)
+				requirePixel(t, paperColor, img, 80, 147)
+				requirePixel(t, textColor, img, 82, 147)
+
+				// Sprite 20 has Y 0x10, the 1 is drawn after the index and the sprite
+				requirePixel(t, paperColor, img, 120+32, 3)
+				requirePixel(t, textColor, img, 120+34, 3)
+				requirePixel(t, textColor, img, 120+40, 3)
+			},
+		},
+		{
+			name: "PaletteSheet",
+			test: func(t *testing.T, g *GPU) {
+				// BGP is reversed and OBP1 maps every color to 3
+				g.Write(0xff47, 0x1b)
+				g.Write(0xff48, 0xe4)
+				g.Write(0xff49, 0xff)
+
+				img := g.PaletteSheet()
+				requirePixel(t, grays[3], img, 0, 0)
+				requirePixel(t, grays[0], img, 3*swatchSize, 0)
+				requirePixel(t, grays[1], img, 5*swatchSize, 0)
+				requirePixel(t, grays[3], img, 4*swatchSize, swatchSize)
+				requirePixel(t, backdropColor, img, 0, swatchSize)
+			},
+		},
+		{
+			name: "CGB",
+			test: func(t *testing.T, g *GPU) {
+				g.color = true
+				red := RGB(31, 0, 0)
+				writePalettes(g, 0xff6a, 3*8+2*2, red)
+
+				require.Equal(t, image.Rect(0, 0, 256, 192), g.TileSheet().Bounds())
+				requirePixel(t, red, g.PaletteSheet(), 6*swatchSize, 3*swatchSize)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := New(make(mmu.RAM, 0x10000), false)
+			g.Write(0xff47, 0xe4)
+			g.Write(0xff48, 0xe4)
+			test.test(t, g)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package gpu
+
+// fetcherReady is the fetcher step at which a tile has been fetched, the fetcher stays there until its pixels can be
+// pushed into the background FIFO
+const fetcherReady = 5
+
+// pixelFIFO is the state of the pixel FIFO renderer for the line being drawn.  The fetcher reads a tile in three steps
+// of two dots each (tile number, low byte, high byte) and pushes its 8 pixels once the background FIFO is empty,
+// while the shifter pops one pixel per dot onto the screen.  Drawing ends when 160 pixels have been shifted out, so
+// the length of mode 3 depends on the fine scroll, the window and the sprites on the line.
+type pixelFIFO struct {
+	// x is the screen column of the next pixel shifted out
+	x int
+	// discard is the number of pixels still to be dropped for the fine scroll
+	discard int
+
+	// bg holds the background pixels, the last bgLen of them are still to be shifted out
+	bg    [8]bgPixel
+	bgLen int
+	obj   [8]objPixel
+
+	// fetcher state: the current step, the tile column, the tile being fetched with its attributes and pixel data
+	step       int
+	tileX      int
+	tile       uint8
+	attributes uint8
+	lo, hi     uint8
+	// dummy is set during the first fetch of a line, whose pixels are thrown away
+	dummy bool
+
+	// window is set once the window has started on this line
+	window bool
+
+	// sprites are the sprites on the line in drawing order, next is the first one that hasn't been fetched
+	sprites []sprite
+	next    int
+	// pending is the sprite being fetched, it stalls the shifter for spriteDots
+	pending    *sprite
+	spriteDots int
+}
+
+// startFIFO sets up the pixel FIFO at the start of drawing
+func (g *GPU) startFIFO() {
+	g.fifo = pixelFIFO{
+		discard: int(g.scx % 8),
+		dummy:   true,
+		sprites: g.scanOAM(),
+	}
+}
+
+// fifoDone reports if all pixels of the line have been drawn
+func (g *GPU) fifoDone() bool {
+	return g.fifo.x >= Width
+}
+
+// stepFIFO advances the pixel FIFO by a single dot
+func (g *GPU) stepFIFO() {
+	f := &g.fifo
+
+	if f.pending != nil {
+		// The background fetch is finished before the sprite is fetched, which takes another 6 dots
+		if f.step < fetcherReady || f.bgLen == 0 {
+			g.stepFetcher()
+			return
+		}
+		f.spriteDots++
+		if f.spriteDots == 6 {
+			g.mergeSprite(*f.pending)
+			f.pending = nil
+		}
+		return
+	}
+
+	if f.discard == 0 && !f.window && g.windowVisible() && f.x >= int(g.wx)-7 {
+		// The window restarts the fetcher, the pixels of the background that are already in the FIFO are dropped
+		f.window = true
+		f.bgLen = 0
+		f.step, f.tileX, f.dummy = 0, 0, false
+		if g.wx < 7 {
+			f.discard = 7 - int(g.wx)
+		}
+	}
+
+	if f.discard == 0 && g.lcdc&lcdcOBJEnable != 0 && f.next < len(f.sprites) && f.sprites[f.next].x <= f.x {
+		f.pending = &f.sprites[f.next]
+		f.spriteDots = 0
+		f.next++
+		g.stepFIFO()
+		return
+	}
+
+	if f.bgLen > 0 {
+		g.shiftPixel()
+	}
+	g.stepFetcher()
+}
+
+// stepFetcher advances the background fetcher by a dot
+func (g *GPU) stepFetcher() {
+	f := &g.fifo
+
+	switch f.step {
+	case 1:
+		f.tile, f.attributes = g.fetchTile()
+	case 3:
+		f.lo = g.vram[g.fetchRow()]
+	case fetcherReady:
+		f.hi = g.vram[g.fetchRow()+1]
+	}
+
+	if f.step < fetcherReady {
+		f.step++
+		return
+	}
+
+	if f.bgLen > 0 {
+		return
+	}
+	if !f.dummy {
+		for x := range f.bg {
+			bit := 7 - g.flipX(uint8(x), f.attributes)
+			f.bg[x] = bgPixel{color: (f.hi>>bit&1)<<1 | f.lo>>bit&1, attributes: f.attributes}
+		}
+		f.bgLen = len(f.bg)
+		f.tileX++
+	}
+	f.step, f.dummy = 0, false
+}
+
+// fetchTile reads the number and attributes of the next background or window tile from the tile map.  SCX and SCY
+// are read at every fetch, so writes to them while drawing take effect at the next tile.
+func (g *GPU) fetchTile() (uint8, uint8) {
+	f := &g.fifo
+	base, x, y := uint16(0x9800), uint8((int(g.scx/8)+f.tileX)&31), (g.ly+g.scy)/8
+	if f.window {
+		if g.lcdc&lcdcWindowMap != 0 {
+			base = 0x9c00
+		}
+		x, y = uint8(f.tileX&31), uint8(g.windowLine/8)
+	} else if g.lcdc&lcdcBGMap != 0 {
+		base = 0x9c00
+	}
+	return g.vram[base-0x8000+uint16(y)*32+uint16(x)], g.mapAttributes(base, x, y)
+}
+
+// fetchRow returns the VRAM offset of the row of the tile being fetched
+func (g *GPU) fetchRow() uint16 {
+	y := g.ly + g.scy
+	if g.fifo.window {
+		y = uint8(g.windowLine)
+	}
+	y = g.flipY(y%8, g.fifo.attributes)
+	return g.bgTileAddress(g.fifo.tile, g.fifo.attributes) - 0x8000 + uint16(y)*2
+}
+
+// mergeSprite loads the pixels of a sprite into the sprite FIFO, replacing the pixels of sprites with lower priority
+func (g *GPU) mergeSprite(s sprite) {
+	f := &g.fifo
+	height := g.spriteHeight()
+	for x := s.x; x < s.x+8; x++ {
+		if x < f.x {
+			continue
+		}
+		slot := &f.obj[x-f.x]
+		if !g.wins(s, *slot) {
+			continue
+		}
+		if color := g.spritePixel(s, height, x); color != 0 {
+			*slot = objPixel{color: color, attributes: s.attributes, index: s.index}
+		}
+	}
+}
+
+// shiftPixel pops a pixel from the FIFOs and draws it.  The palettes and LCDC are read as the pixel is drawn, so writes
+// to them while drawing affect the rest of the line.
+func (g *GPU) shiftPixel() {
+	f := &g.fifo
+	bg := f.bg[len(f.bg)-f.bgLen]
+	f.bgLen--
+
+	if f.discard > 0 {
+		f.discard--
+		return
+	}
+
+	obj := f.obj[0]
+	copy(f.obj[:], f.obj[1:])
+	f.obj[len(f.obj)-1] = objPixel{}
+
+	g.drawPixel(f.x, bg, f.window, obj)
+	f.x++
+}
@@ -0,0 +1,135 @@
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// drawingLength runs the GPU to the end of drawing on the current line and returns how many dots drawing took
+func drawingLength(g *GPU) int {
+	for g.mode != modeDrawing {
+		g.Next(1)
+	}
+	dots := 0
+	for g.mode == modeDrawing {
+		g.Next(1)
+		dots++
+	}
+	return dots
+}
+
+func TestFIFO(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T, g *GPU, ram mmu.RAM)
+	}{
+		{
+			name: "Plain",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				require.Equal(t, drawingDots, drawingLength(g))
+			},
+		},
+		{
+			name: "FineScroll",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.Write(0xff43, 3)
+				require.Equal(t, drawingDots+3, drawingLength(g))
+			},
+		},
+		{
+			name: "Window",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.Write(0xff4b, 7+80)
+				g.Write(0xff40, g.lcdc|lcdcBGEnable|lcdcWindowEnable)
+				require.Equal(t, drawingDots+6, drawingLength(g))
+			},
+		},
+		{
+			name: "Sprites",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.oam[0], g.oam[1] = 16, 8+40
+				g.Write(0xff40, g.lcdc|lcdcOBJEnable)
+				length := drawingLength(g)
+				require.True(t, length >= drawingDots+6 && length <= drawingDots+11, "drawing took %d dots", length)
+
+				// A line without sprites is back to normal
+				g.oam[0] = 0
+				g.Next(dotsPerLine - oamScanDots - length)
+				require.Equal(t, drawingDots, drawingLength(g))
+			},
+		},
+		{
+			name: "HBlankInterrupt",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// The HBlank interrupt comes later when drawing takes longer
+				g.Write(0xff41, statHBlankSource)
+				g.Write(0xff43, 7)
+				g.Next(oamScanDots + drawingDots)
+				require.Zero(t, ram[0xff0f])
+				g.Next(7)
+				require.EqualValues(t, interruptLCDSTAT, ram[0xff0f])
+			},
+		},
+		{
+			name: "MidLinePalette",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Tile 0 is solid color 1 and covers the whole background
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8000+i, 0xff)
+				}
+				g.Write(0xff47, 0x04)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcTileData)
+
+				// Change BGP half way through the line
+				g.Next(oamScanDots + drawingDots/2)
+				g.Write(0xff47, 0x0c)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, grays[1], f[0][0])
+				require.Equal(t, grays[3], f[0][Width-1])
+				require.Equal(t, grays[3], f[1][0])
+			},
+		},
+		{
+			name: "MidLineSCX",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Tile 1 is solid color 3 and is only placed in column 31 of the tile map
+				for i := uint16(0); i < 16; i++ {
+					g.Write(0x8010+i, 0xff)
+				}
+				g.Write(0x9800+31, 0x01)
+				g.Write(0xff47, 0xe4)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcTileData)
+
+				// Scrolling by 12 tiles half way through the line makes column 31 visible in the last 8 pixels
+				g.Next(oamScanDots + drawingDots/2)
+				g.Write(0xff43, 12*8)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, grays[0], f[0][Width-9])
+				require.Equal(t, grays[3], f[0][Width-8])
+				require.Equal(t, grays[3], f[0][Width-1])
+
+				// The next line is scrolled from its start
+				require.Equal(t, grays[3], f[1][Width-8])
+				require.Equal(t, grays[0], f[1][Width-1-8*4])
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ram := make(mmu.RAM, 0x10000)
+			g := New(ram, false)
+			g.SetRenderer(FIFORenderer)
+			g.Write(0xff40, lcdcEnable)
+
+			test.test(t, g, ram)
+		})
+	}
+}
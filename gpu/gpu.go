@@ -4,8 +4,106 @@ package gpu
 // Palettes
 // Layers -> Background, Window, Objects
 
+const (
+	// Each line takes 456 dots, the 144 visible lines are followed by 10 lines of VBlank
+	dotsPerLine  = 456
+	lines        = 154
+	visibleLines = 144
+
+	// Every visible line starts with 80 dots of OAM scan, followed by drawing and then HBlank
+	oamScanDots = 80
+	drawingDots = 172
+)
+
+// mode is the PPU mode, as reported in the lowest two bits of STAT
+type mode uint8
+
+const (
+	modeHBlank mode = iota
+	modeVBlank
+	modeOAMScan
+	modeDrawing
+)
+
+// Interrupts requested by the GPU through the IF register
+const (
+	interruptVBlank  = 0x01
+	interruptLCDSTAT = 0x02
+)
+
+// LCDC bits
+const (
+	lcdcBGEnable     = 0x01
+	lcdcOBJEnable    = 0x02
+	lcdcOBJSize      = 0x04
+	lcdcBGMap        = 0x08
+	lcdcTileData     = 0x10
+	lcdcWindowEnable = 0x20
+	lcdcWindowMap    = 0x40
+	lcdcEnable       = 0x80
+)
+
+// STAT bits
+const (
+	statCoincidence  = 0x04
+	statHBlankSource = 0x08
+	statVBlankSource = 0x10
+	statOAMSource    = 0x20
+	statLYCSource    = 0x40
+)
+
 type GPU struct {
 	ram Memory
+
+	// color is set when running in CGB mode, where tiles have attributes and palettes are stored in palette RAM
+	color bool
+
+	// vram holds both banks, bank 1 only exists on the CGB and follows bank 0
+	vram [0x4000]uint8
+	oam  [0xa0]uint8
+	vbk  uint8
+
+	lcdc uint8
+	stat uint8
+	scy  uint8
+	scx  uint8
+	ly   uint8
+	lyc  uint8
+	bgp  uint8
+	obp0 uint8
+	obp1 uint8
+	wy   uint8
+	wx   uint8
+	dma  uint8
+
+	// CGB palette RAM and the index registers BCPS and OCPS used to access it
+	bcps        uint8
+	ocps        uint8
+	bgPalettes  [64]uint8
+	objPalettes [64]uint8
+
+	// dmgColors are the colors of the shades of each layer when not running in CGB mode
+	dmgColors [3][4]Color
+
+	// dot is the position within the current line
+	dot  int
+	mode mode
+
+	// statLine is the state of the STAT interrupt line, the interrupt is only requested when it goes high
+	statLine bool
+
+	// back is rendered into line by line and becomes front at the start of VBlank
+	front *Frame
+	back  *Frame
+	// windowLine is the internal line counter of the window, it only advances on lines where the window is drawn
+	windowLine int
+
+	renderer Renderer
+	fifo     pixelFIFO
+
+	// hidden are the layers that aren't drawn, overlays the debug overlays that are drawn over the screen
+	hidden   Layers
+	overlays Overlays
 }
 
 type Memory interface {
@@ -13,13 +111,174 @@ type Memory interface {
 	Write(a uint16, v uint8)
 }
 
-func New(ram Memory) *GPU {
+func New(ram Memory, color bool) *GPU {
 	g := &GPU{
-		ram: ram,
+		ram:   ram,
+		color: color,
+		front: &Frame{},
+		back:  &Frame{},
+	}
+	g.SetDMGColors(grays, grays, grays)
+	g.blank()
+	if color {
+		// The boot ROM initializes the background palettes to white
+		for i := range g.bgPalettes {
+			g.bgPalettes[i] = 0xff
+		}
 	}
 	return g
 }
 
-func (g *GPU) Next() {
+// Reset returns the registers and the state of the GPU to their power-on values.  VRAM, OAM and palette RAM keep their
+// contents, as they do when the Game Boy is reset without being power cycled.
+func (g *GPU) Reset() {
+	vram, oam, bgPalettes, objPalettes := g.vram, g.oam, g.bgPalettes, g.objPalettes
+	*g = *New(g.ram, g.color)
+	g.vram, g.oam, g.bgPalettes, g.objPalettes = vram, oam, bgPalettes, objPalettes
+}
+
+// SetRenderer selects how the screen is drawn.  Switching to the FIFO renderer while a line is drawn ends drawing,
+// leaving the rest of the line as it was.
+func (g *GPU) SetRenderer(r Renderer) {
+	if r == FIFORenderer && g.renderer != r && g.mode == modeDrawing {
+		g.fifo = pixelFIFO{x: Width}
+	}
+	g.renderer = r
+}
+
+// Next advances the GPU by the given number of dots, it returns true if VBlank started
+func (g *GPU) Next(dots int) bool {
+	vblank := false
+	for i := 0; i < dots; i++ {
+		if g.tick() {
+			vblank = true
+		}
+	}
+	return vblank
+}
+
+// enabled reports if the LCD is on
+func (g *GPU) enabled() bool {
+	return g.lcdc&lcdcEnable != 0
+}
+
+// tick advances the GPU by a single dot, it returns true if VBlank started
+func (g *GPU) tick() bool {
+	if !g.enabled() {
+		return false
+	}
+
+	g.dot++
+	if g.dot == dotsPerLine {
+		g.dot = 0
+		g.ly++
+		if g.ly == lines {
+			g.ly = 0
+		}
+	}
+
+	var next mode
+	switch {
+	case g.ly >= visibleLines:
+		next = modeVBlank
+	case g.dot < oamScanDots:
+		next = modeOAMScan
+	case g.renderer == FIFORenderer:
+		// Drawing lasts until the FIFO has drawn the whole line
+		next = modeDrawing
+		if g.mode == modeDrawing && g.fifoDone() {
+			next = modeHBlank
+		} else if g.mode == modeHBlank {
+			next = modeHBlank
+		}
+	case g.dot < oamScanDots+drawingDots:
+		next = modeDrawing
+	default:
+		next = modeHBlank
+	}
+
+	vblank := false
+	if next != g.mode {
+		switch next {
+		case modeDrawing:
+			if g.renderer == FIFORenderer {
+				g.startFIFO()
+			}
+		case modeHBlank:
+			if g.renderer == FIFORenderer {
+				if g.fifo.window {
+					g.windowLine++
+				}
+				break
+			}
+			// The line is rendered in one go at the end of drawing
+			g.renderLine()
+		case modeVBlank:
+			g.drawOverlays()
+			g.swap()
+			g.interrupt(interruptVBlank)
+			vblank = true
+		}
+		g.mode = next
+	}
+
+	if g.mode == modeDrawing && g.renderer == FIFORenderer {
+		g.stepFIFO()
+	}
+
+	g.updateStat()
+	return vblank
+}
 
+// updateStat updates the mode and coincidence bits of STAT and requests the STAT interrupt when the interrupt line
+// goes high.  Since the sources share a single line, a source that becomes active while another one already is does
+// not cause another interrupt, this is known as STAT blocking.
+func (g *GPU) updateStat() {
+	g.stat = g.stat&0xf8 | uint8(g.mode)
+	if g.enabled() && g.ly == g.lyc {
+		g.stat |= statCoincidence
+	}
+
+	line := false
+	switch g.mode {
+	case modeHBlank:
+		line = g.stat&statHBlankSource != 0
+	case modeVBlank:
+		// The OAM source also fires at the start of VBlank
+		line = g.stat&statVBlankSource != 0 || (g.ly == visibleLines && g.dot == 0 && g.stat&statOAMSource != 0)
+	case modeOAMScan:
+		line = g.stat&statOAMSource != 0
+	}
+	if g.stat&statLYCSource != 0 && g.stat&statCoincidence != 0 {
+		line = true
+	}
+
+	if line && !g.statLine {
+		g.interrupt(interruptLCDSTAT)
+	}
+	g.statLine = line
+}
+
+// interrupt requests an interrupt by setting its bit in IF
+func (g *GPU) interrupt(i uint8) {
+	g.ram.Write(0xff0f, g.ram.Read(0xff0f)|i)
+}
+
+// setLCDC handles writes to LCDC, turning the LCD off resets LY and puts the GPU in HBlank
+func (g *GPU) setLCDC(v uint8) {
+	wasEnabled := g.enabled()
+	g.lcdc = v
+
+	switch {
+	case wasEnabled && !g.enabled():
+		g.ly, g.dot, g.mode = 0, 0, modeHBlank
+		g.statLine = false
+		g.updateStat()
+
+		// The screen is blank while the LCD is off
+		g.blank()
+	case !wasEnabled && g.enabled():
+		g.ly, g.dot, g.mode = 0, 0, modeOAMScan
+		g.updateStat()
+	}
 }
@@ -0,0 +1,370 @@
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+func TestGPU(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T, g *GPU, ram mmu.RAM)
+	}{
+		{
+			name: "Modes",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.Next(oamScanDots - 1)
+				require.EqualValues(t, modeOAMScan, g.Read(0xff41)&0x03)
+				g.Next(1)
+				require.EqualValues(t, modeDrawing, g.Read(0xff41)&0x03)
+				g.Next(drawingDots)
+				require.EqualValues(t, modeHBlank, g.Read(0xff41)&0x03)
+				g.Next(dotsPerLine - oamScanDots - drawingDots)
+				require.EqualValues(t, 1, g.Read(0xff44))
+				require.EqualValues(t, modeOAMScan, g.Read(0xff41)&0x03)
+			},
+		},
+		{
+			name: "VBlank",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				require.False(t, g.Next(visibleLines*dotsPerLine-1))
+				require.Zero(t, ram[0xff0f])
+				require.True(t, g.Next(1))
+				require.EqualValues(t, visibleLines, g.Read(0xff44))
+				require.EqualValues(t, modeVBlank, g.Read(0xff41)&0x03)
+				require.EqualValues(t, interruptVBlank, ram[0xff0f])
+
+				// LY wraps after line 153
+				g.Next((lines - visibleLines) * dotsPerLine)
+				require.EqualValues(t, 0, g.Read(0xff44))
+			},
+		},
+		{
+			name: "LYC",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.Write(0xff45, 2)
+				g.Write(0xff41, statLYCSource)
+				g.Next(2 * dotsPerLine)
+				require.EqualValues(t, 2, g.Read(0xff44))
+				require.NotZero(t, g.Read(0xff41)&statCoincidence)
+				require.EqualValues(t, interruptLCDSTAT, ram[0xff0f])
+
+				g.Next(dotsPerLine)
+				require.Zero(t, g.Read(0xff41)&statCoincidence)
+			},
+		},
+		{
+			name: "STATBlocking",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// HBlank of line 0 runs straight into OAM scan of line 1, so only the first raises an interrupt
+				g.Write(0xff41, statHBlankSource|statOAMSource)
+				g.Next(oamScanDots + drawingDots + 1)
+				require.EqualValues(t, interruptLCDSTAT, ram[0xff0f])
+
+				ram[0xff0f] = 0
+				g.Next(dotsPerLine - oamScanDots - drawingDots)
+				require.EqualValues(t, modeOAMScan, g.Read(0xff41)&0x03)
+				require.Zero(t, ram[0xff0f])
+			},
+		},
+		{
+			name: "LCDOff",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				g.Next(3 * dotsPerLine)
+				g.Write(0xff40, 0x00)
+				require.EqualValues(t, 0, g.Read(0xff44))
+				require.EqualValues(t, modeHBlank, g.Read(0xff41)&0x03)
+
+				require.False(t, g.Next(lines*dotsPerLine))
+				require.EqualValues(t, 0, g.Read(0xff44))
+
+				// VRAM is accessible while the LCD is off
+				g.Write(0x8000, 0x12)
+				require.EqualValues(t, 0x12, g.Read(0x8000))
+			},
+		},
+		{
+			name: "Background",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Tile 1 has a single pixel of color 3 in its top left corner, tile 0 is blank
+				g.Write(0x8010, 0x80)
+				g.Write(0x8011, 0x80)
+				// Place tile 1 at map position 1, 1 and scroll so that it is drawn at 0, 0
+				g.Write(0x9821, 0x01)
+				g.Write(0xff42, 8)
+				g.Write(0xff43, 8)
+				g.Write(0xff47, 0xe4)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcTileData)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				require.Equal(t, grays[3], g.Frame()[0][0])
+				require.Equal(t, grays[0], g.Frame()[0][1])
+				require.Equal(t, grays[0], g.Frame()[1][0])
+
+				// Scrolling wraps around the 256x256 map
+				g.Write(0xff42, 8+256-10)
+				g.Write(0xff43, 8+256-20)
+				g.Next((lines - visibleLines) * dotsPerLine)
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				require.Equal(t, grays[3], g.Frame()[10][20])
+			},
+		},
+		{
+			name: "Window",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Tile 0x80 from the 0x8800 tile data is solid color 1, used by the window map at 0x9c00
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8800+i, 0xff)
+				}
+				for i := uint16(0); i < 32*32; i++ {
+					g.Write(0x9c00+i, 0x80)
+				}
+				g.Write(0xff4a, 100)
+				g.Write(0xff4b, 7+50)
+				g.Write(0xff47, 0xe4)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcWindowEnable|lcdcWindowMap)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				require.Equal(t, grays[0], g.Frame()[99][50])
+				require.Equal(t, grays[0], g.Frame()[100][49])
+				require.Equal(t, grays[1], g.Frame()[100][50])
+				require.Equal(t, grays[1], g.Frame()[143][159])
+			},
+		},
+		{
+			name: "Sprites",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Tile 1 is solid color 1, tile 2 solid color 2 and tile 3 has color 3 in its top left pixel only
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8010+i, 0xff)
+					g.Write(0x8021+i, 0xff)
+				}
+				g.Write(0x8030, 0x80)
+				g.Write(0x8031, 0x80)
+
+				// Sprites are loaded with OAM DMA from 0xc000
+				sprites := []uint8{
+					// Y, X, tile, attributes
+					16, 8 + 4, 1, 0, // 0: x 4, tile 1
+					16, 8, 2, 0, // 1: x 0, tile 2, wins over sprite 0 since it has the smaller X
+					16, 8 + 20, 3, attrXFlip | attrYFlip, // 2: flipped, so its pixel is at the bottom right
+					16, 8 + 40, 1, attrPriority | attrPalette, // 3: behind the background
+				}
+				copy(ram[0xc000:], sprites)
+				g.Write(0xff46, 0xc0)
+
+				// The background is color 0 except for a color 1 pixel under sprite 3
+				g.Write(0x8040, 0x80)
+				g.Write(0x9805, 0x04)
+
+				g.Write(0xff47, 0xe4)
+				g.Write(0xff48, 0xe4)
+				g.Write(0xff49, 0x1b)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcOBJEnable|lcdcTileData)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, grays[2], f[0][4])
+				require.Equal(t, grays[2], f[0][7])
+				require.Equal(t, grays[1], f[0][8])
+				require.Equal(t, grays[1], f[0][11])
+				require.Equal(t, grays[0], f[0][12])
+
+				require.Equal(t, grays[0], f[0][20])
+				require.Equal(t, grays[3], f[7][27])
+
+				// Sprite 3 uses OBP1, which inverts the shades, and is only visible where the background is color 0
+				require.Equal(t, grays[1], f[0][40])
+				require.Equal(t, grays[2], f[0][41])
+			},
+		},
+		{
+			name: "DMAEcho",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				// Sources above work RAM fold back onto it, the register reads back as written
+				ram[0xde00], ram[0xde9f] = 0x12, 0x34
+				ram[0xfe00] = 0x56
+				g.Write(0xff46, 0xfe)
+				require.EqualValues(t, 0xfe, g.Read(0xff46))
+				require.EqualValues(t, 0x12, g.oam[0x00])
+				require.EqualValues(t, 0x34, g.oam[0x9f])
+
+				ram[0xc000] = 0x78
+				g.Write(0xff46, 0xe0)
+				require.EqualValues(t, 0x78, g.oam[0x00])
+			},
+		},
+		{
+			name: "SpriteLimit",
+			test: func(t *testing.T, g *GPU, ram mmu.RAM) {
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8010+i, 0xff)
+				}
+				// 12 tall sprites on line 0, the last two are not drawn.  The sprite size is selected by LCDC so
+				// 8x16 sprites also select tile 1 as their bottom half.
+				for i := 0; i < 12; i++ {
+					g.oam[i*4], g.oam[i*4+1], g.oam[i*4+2] = 16-8, uint8(8+i*8), 0x00
+				}
+				g.Write(0xff48, 0xe4)
+				g.Write(0xff40, lcdcEnable|lcdcOBJEnable|lcdcOBJSize)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				require.Equal(t, grays[1], g.Frame()[0][9*8])
+				require.Equal(t, grays[0], g.Frame()[0][10*8])
+			},
+		},
+	}
+
+	// Both renderers must draw the same frames
+	for _, renderer := range []Renderer{ScanlineRenderer, FIFORenderer} {
+		for _, test := range tests {
+			t.Run(renderer.String()+"/"+test.name, func(t *testing.T) {
+				ram := make(mmu.RAM, 0x10000)
+				g := New(ram, false)
+				g.SetRenderer(renderer)
+				g.Write(0xff40, lcdcEnable)
+
+				test.test(t, g, ram)
+			})
+		}
+	}
+}
+
+// writePalettes loads colors into CGB palette RAM through the index and data register at a, starting at index
+func writePalettes(g *GPU, a uint16, index uint8, colors ...Color) {
+	g.Write(a, 0x80|index)
+	for _, c := range colors {
+		g.Write(a+1, uint8(c))
+		g.Write(a+1, uint8(c>>8))
+	}
+}
+
+func TestCGB(t *testing.T) {
+	red, green, blue, white := RGB(31, 0, 0), RGB(0, 31, 0), RGB(0, 0, 31), RGB(31, 31, 31)
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T, g *GPU)
+	}{
+		{
+			name: "PaletteRAM",
+			test: func(t *testing.T, g *GPU) {
+				// The background palettes start out white
+				g.Write(0xff68, 0x3e)
+				require.EqualValues(t, 0xff, g.Read(0xff69))
+
+				writePalettes(g, 0xff68, 0x02, red)
+				require.EqualValues(t, 0xc4, g.Read(0xff68))
+				g.Write(0xff68, 0x02)
+				require.EqualValues(t, 0x1f, g.Read(0xff69))
+
+				// Without bit 7 the index doesn't move
+				g.Write(0xff6b, 0x12)
+				g.Write(0xff6b, 0x34)
+				require.EqualValues(t, 0x40, g.Read(0xff6a))
+				require.EqualValues(t, 0x34, g.Read(0xff6b))
+
+				// Palette RAM is inaccessible while drawing, but the index still increments
+				g.Next(oamScanDots)
+				g.Write(0xff68, 0x80)
+				g.Write(0xff69, 0x00)
+				require.EqualValues(t, 0xff, g.Read(0xff69))
+				require.EqualValues(t, 0xc1, g.Read(0xff68))
+				g.Next(drawingDots)
+				g.Write(0xff68, 0x00)
+				require.EqualValues(t, 0xff, g.Read(0xff69))
+			},
+		},
+		{
+			name: "VRAMBank",
+			test: func(t *testing.T, g *GPU) {
+				g.Write(0x8000, 0x12)
+				g.Write(0xff4f, 0x01)
+				require.EqualValues(t, 0xff, g.Read(0xff4f))
+				require.EqualValues(t, 0x00, g.Read(0x8000))
+				g.Write(0x8000, 0x34)
+				g.Write(0xff4f, 0x00)
+				require.EqualValues(t, 0xfe, g.Read(0xff4f))
+				require.EqualValues(t, 0x12, g.Read(0x8000))
+			},
+		},
+		{
+			name: "Attributes",
+			test: func(t *testing.T, g *GPU) {
+				// Tile 0 in bank 1 has color 1 in its top left pixel only
+				g.Write(0xff4f, 0x01)
+				g.Write(0x8000, 0x80)
+				// Tile 0 of the map uses palette 2 from bank 1 and is flipped both ways
+				g.Write(0x9800, 0x02|bgAttrBank|bgAttrXFlip|bgAttrYFlip)
+				g.Write(0xff4f, 0x00)
+
+				writePalettes(g, 0xff68, 2*8, white, red)
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcTileData)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, white, f[0][0])
+				require.Equal(t, red, f[7][7])
+			},
+		},
+		{
+			name: "Priority",
+			test: func(t *testing.T, g *GPU) {
+				// Tile 0 is solid color 1, tile 1 is transparent.  The first two columns of the map have their
+				// priority bit set.
+				for i := uint16(0); i < 16; i += 2 {
+					g.Write(0x8000+i, 0xff)
+				}
+				g.Write(0xff4f, 0x01)
+				g.Write(0x9800, bgAttrPriority)
+				g.Write(0x9801, bgAttrPriority)
+				g.Write(0xff4f, 0x00)
+				for x := uint16(2); x < 32; x++ {
+					g.Write(0x9800+x, 0x01)
+				}
+
+				writePalettes(g, 0xff68, 0, white, red)
+				writePalettes(g, 0xff6a, 0, white, green)
+				writePalettes(g, 0xff6a, 1*8, white, blue)
+
+				// Sprite 1 at x 0 and sprite 0 at x 4 overlap, sprite 0 wins on the CGB even though it is further
+				// right.  Sprite 2 is on a transparent background tile.
+				g.oam = [0xa0]uint8{
+					16, 8 + 4, 0, 1,
+					16, 8, 0, 0,
+					16, 8 + 24, 0, 0,
+				}
+				g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcOBJEnable|lcdcTileData)
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, red, f[0][0])
+				require.Equal(t, red, f[0][15])
+				require.Equal(t, green, f[0][24])
+
+				// Clearing LCDC bit 0 puts sprites on top of everything
+				g.Write(0xff40, lcdcEnable|lcdcOBJEnable|lcdcTileData)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f = g.Frame()
+				require.Equal(t, green, f[0][0])
+				require.Equal(t, blue, f[0][4])
+				require.Equal(t, blue, f[0][11])
+				require.Equal(t, red, f[0][12])
+			},
+		},
+	}
+
+	for _, renderer := range []Renderer{ScanlineRenderer, FIFORenderer} {
+		for _, test := range tests {
+			t.Run(renderer.String()+"/"+test.name, func(t *testing.T) {
+				g := New(make(mmu.RAM, 0x10000), true)
+				g.SetRenderer(renderer)
+				g.Write(0xff40, lcdcEnable)
+
+				test.test(t, g)
+			})
+		}
+	}
+}
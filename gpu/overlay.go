@@ -0,0 +1,215 @@
+package gpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Layers is a set of the layers that make up the screen
+type Layers uint8
+
+const (
+	LayerBackground Layers = 1 << iota
+	LayerWindow
+	LayerSprites
+)
+
+var layerNames = map[Layers]string{
+	LayerBackground: "bg",
+	LayerWindow:     "window",
+	LayerSprites:    "sprites",
+}
+
+func (l Layers) String() string {
+	return setString(uint8(l), func(bit uint8) string { return layerNames[Layers(bit)] })
+}
+
+// ParseLayers parses a comma separated list of layers: bg, window and sprites
+func ParseLayers(s string) (Layers, error) {
+	var l Layers
+	err := parseSet(s, func(name string) bool {
+		for layer, n := range layerNames {
+			if n == name {
+				l |= layer
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unknown layer %w", err)
+	}
+	return l, nil
+}
+
+// Overlays is a set of debug overlays drawn over the screen
+type Overlays uint8
+
+const (
+	// OverlaySpriteBoxes outlines the sprites in OAM that are on the screen
+	OverlaySpriteBoxes Overlays = 1 << iota
+	// OverlayWindowOrigin draws the top and left edges of the window
+	OverlayWindowOrigin
+	// OverlayLayerTint tints each pixel by the layer that produced it: red for the background, green for the window
+	// and blue for sprites
+	OverlayLayerTint
+)
+
+var overlayNames = map[Overlays]string{
+	OverlaySpriteBoxes:  "boxes",
+	OverlayWindowOrigin: "window",
+	OverlayLayerTint:    "tint",
+}
+
+func (o Overlays) String() string {
+	return setString(uint8(o), func(bit uint8) string { return overlayNames[Overlays(bit)] })
+}
+
+// ParseOverlays parses a comma separated list of overlays: boxes, window and tint
+func ParseOverlays(s string) (Overlays, error) {
+	var o Overlays
+	err := parseSet(s, func(name string) bool {
+		for overlay, n := range overlayNames {
+			if n == name {
+				o |= overlay
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unknown overlay %w", err)
+	}
+	return o, nil
+}
+
+// setString joins the names of the bits that are set in v
+func setString(v uint8, name func(bit uint8) string) string {
+	var names []string
+	for bit := uint8(1); bit != 0; bit <<= 1 {
+		if v&bit == 0 {
+			continue
+		}
+		if n := name(bit); n != "" {
+			names = append(names, n)
+		} else {
+			names = append(names, fmt.Sprintf("0x%02x", bit))
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// parseSet calls add for each name in a comma separated list, it returns an error for the first name add rejects
+func parseSet(s string, add func(name string) bool) error {
+	if s == "" || s == "none" {
+		return nil
+	}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); !add(name) {
+			return fmt.Errorf("%q", name)
+		}
+	}
+	return nil
+}
+
+// Colors of the overlays
+var (
+	spriteBoxColor    = RGB(31, 31, 0)
+	windowOriginColor = RGB(0, 31, 31)
+
+	backgroundTint = RGB(31, 0, 0)
+	windowTint     = RGB(0, 31, 0)
+	spriteTint     = RGB(0, 0, 31)
+)
+
+// SetHiddenLayers hides layers of the screen.  Hidden background and window pixels are drawn as color 0, and hidden
+// sprites as if they were transparent.  The GPU runs exactly as it would otherwise.
+func (g *GPU) SetHiddenLayers(l Layers) {
+	g.hidden = l
+}
+
+// HiddenLayers returns the hidden layers
+func (g *GPU) HiddenLayers() Layers {
+	return g.hidden
+}
+
+// SetOverlays selects the debug overlays drawn over the screen
+func (g *GPU) SetOverlays(o Overlays) {
+	g.overlays = o
+}
+
+// Overlays returns the debug overlays that are drawn
+func (g *GPU) Overlays() Overlays {
+	return g.overlays
+}
+
+// drawPixel draws the pixel at column x of the current line to the back frame, from the background or window pixel
+// and the sprite pixel at its position.  A hidden window shows the background below it, which is fetched again with
+// the current scroll registers.
+func (g *GPU) drawPixel(x int, bg bgPixel, window bool, obj objPixel) {
+	if window && g.hidden&LayerWindow != 0 {
+		bg, window = g.backgroundPixel(x), false
+	}
+	if !window && g.hidden&LayerBackground != 0 {
+		bg = bgPixel{}
+	}
+	if g.hidden&LayerSprites != 0 {
+		obj = objPixel{}
+	}
+
+	c := g.mix(bg, obj)
+	if g.overlays&OverlayLayerTint != 0 {
+		tint := backgroundTint
+		switch {
+		case g.objOnTop(bg, obj):
+			tint = spriteTint
+		case window:
+			tint = windowTint
+		}
+		c = blend(c, tint)
+	}
+	g.back[g.ly][x] = c
+}
+
+// drawOverlays draws the sprite boxes and window origin over the back frame once it is complete
+func (g *GPU) drawOverlays() {
+	if g.overlays&OverlayWindowOrigin != 0 && g.lcdc&lcdcWindowEnable != 0 && g.wy < Height && g.wx <= 166 {
+		// The right and bottom edges of the outline are just off the screen
+		x, y := int(g.wx)-7, int(g.wy)
+		g.outline(x, y, Width-x+1, Height-y+1, windowOriginColor)
+	}
+
+	if g.overlays&OverlaySpriteBoxes != 0 {
+		height := g.spriteHeight()
+		for i := 0; i < len(g.oam); i += 4 {
+			g.outline(int(g.oam[i+1])-8, int(g.oam[i])-16, 8, height, spriteBoxColor)
+		}
+	}
+}
+
+// outline draws the edges of a rectangle onto the back frame, the parts outside the screen are left out
+func (g *GPU) outline(x, y, width, height int, c Color) {
+	set := func(x, y int) {
+		if x >= 0 && x < Width && y >= 0 && y < Height {
+			g.back[y][x] = c
+		}
+	}
+	for i := 0; i < width; i++ {
+		set(x+i, y)
+		set(x+i, y+height-1)
+	}
+	for i := 0; i < height; i++ {
+		set(x, y+i)
+		set(x+width-1, y+i)
+	}
+}
+
+// blend returns the average of two colors
+func blend(a, b Color) Color {
+	ar, ag, ab := a.RGB5()
+	br, bg, bb := b.RGB5()
+	return RGB((ar+br)/2, (ag+bg)/2, (ab+bb)/2)
+}
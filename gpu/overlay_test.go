@@ -0,0 +1,147 @@
+package gpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// overlayScene sets up a background of color 1, a window of color 2 from 80, 72 and a sprite of color 3 at 0, 0
+func overlayScene(g *GPU) {
+	for i := uint16(0); i < 16; i += 2 {
+		g.Write(0x8010+i, 0xff)
+		g.Write(0x8021+i, 0xff)
+		g.Write(0x8030+i, 0xff)
+		g.Write(0x8031+i, 0xff)
+	}
+	for i := uint16(0); i < 32*32; i++ {
+		g.Write(0x9800+i, 0x01)
+		g.Write(0x9c00+i, 0x02)
+	}
+	g.Write(0xfe00, 16)
+	g.Write(0xfe01, 8)
+	g.Write(0xfe02, 0x03)
+
+	g.Write(0xff4a, 72)
+	g.Write(0xff4b, 7+80)
+	g.Write(0xff47, 0xe4)
+	g.Write(0xff48, 0xe4)
+	g.Write(0xff40, lcdcEnable|lcdcBGEnable|lcdcOBJEnable|lcdcTileData|lcdcWindowEnable|lcdcWindowMap)
+}
+
+func TestOverlays(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T, g *GPU)
+	}{
+		{
+			name: "Layers",
+			test: func(t *testing.T, g *GPU) {
+				f := g.Frame()
+				require.Equal(t, grays[3], f[0][0])
+				require.Equal(t, grays[1], f[0][10])
+				require.Equal(t, grays[2], f[100][100])
+			},
+		},
+		{
+			name: "HideBackground",
+			test: func(t *testing.T, g *GPU) {
+				g.SetHiddenLayers(LayerBackground)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, grays[3], f[0][0])
+				require.Equal(t, grays[0], f[0][10])
+				require.Equal(t, grays[2], f[100][100])
+			},
+		},
+		{
+			name: "HideWindowAndSprites",
+			test: func(t *testing.T, g *GPU) {
+				g.SetHiddenLayers(LayerWindow | LayerSprites)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, grays[1], f[0][0])
+				require.Equal(t, grays[1], f[0][10])
+				require.Equal(t, grays[1], f[100][100])
+			},
+		},
+		{
+			name: "HideWindowAndBackground",
+			test: func(t *testing.T, g *GPU) {
+				// The background shown below a hidden window is tinted as background
+				g.SetHiddenLayers(LayerWindow | LayerBackground)
+				g.SetOverlays(OverlayLayerTint)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, blend(grays[0], backgroundTint), f[0][10])
+				require.Equal(t, blend(grays[0], backgroundTint), f[100][100])
+			},
+		},
+		{
+			name: "Tint",
+			test: func(t *testing.T, g *GPU) {
+				g.SetOverlays(OverlayLayerTint)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, blend(grays[3], spriteTint), f[0][0])
+				require.Equal(t, blend(grays[1], backgroundTint), f[0][10])
+				require.Equal(t, blend(grays[2], windowTint), f[100][100])
+			},
+		},
+		{
+			name: "SpriteBoxes",
+			test: func(t *testing.T, g *GPU) {
+				g.SetOverlays(OverlaySpriteBoxes)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, spriteBoxColor, f[0][3])
+				require.Equal(t, spriteBoxColor, f[7][7])
+				require.Equal(t, spriteBoxColor, f[3][0])
+				require.Equal(t, grays[3], f[3][3])
+				require.Equal(t, grays[1], f[8][8])
+			},
+		},
+		{
+			name: "WindowOrigin",
+			test: func(t *testing.T, g *GPU) {
+				g.SetOverlays(OverlayWindowOrigin)
+				require.True(t, g.Next(lines*dotsPerLine))
+				f := g.Frame()
+				require.Equal(t, windowOriginColor, f[72][80])
+				require.Equal(t, windowOriginColor, f[72][159])
+				require.Equal(t, windowOriginColor, f[143][80])
+				require.Equal(t, grays[2], f[143][159])
+				require.Equal(t, grays[1], f[71][80])
+			},
+		},
+	}
+
+	for _, renderer := range []Renderer{ScanlineRenderer, FIFORenderer} {
+		for _, test := range tests {
+			t.Run(renderer.String()+"/"+test.name, func(t *testing.T) {
+				g := New(make(mmu.RAM, 0x10000), false)
+				g.SetRenderer(renderer)
+				overlayScene(g)
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+
+				test.test(t, g)
+			})
+		}
+	}
+}
+
+func TestParseLayers(t *testing.T) {
+	l, err := ParseLayers("bg, sprites")
+	require.NoError(t, err)
+	require.Equal(t, LayerBackground|LayerSprites, l)
+	require.Equal(t, "bg,sprites", l.String())
+
+	o, err := ParseOverlays("none")
+	require.NoError(t, err)
+	require.Equal(t, "none", o.String())
+
+	_, err = ParseOverlays("boxes,grid")
+	require.EqualError(t, err, `unknown overlay "grid"`)
+}
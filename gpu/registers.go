@@ -0,0 +1,159 @@
+package gpu
+
+// Read implements mmu.ReadWriter for VRAM (0x8000 - 0x9fff), OAM (0xfe00 - 0xfe9f), the LCD registers
+// (0xff40 - 0xff4b) including OAM DMA, and the CGB VRAM bank (0xff4f) and palette registers (0xff68 - 0xff6b).  VRAM
+// and palette RAM can't be read while drawing and OAM can't be read during OAM scan or drawing.
+func (g *GPU) Read(a uint16) uint8 {
+	switch {
+	case a >= 0x8000 && a < 0xa000:
+		if g.mode == modeDrawing {
+			return 0xff
+		}
+		return g.vram[g.vramOffset(a)]
+
+	case a >= 0xfe00 && a < 0xfea0:
+		if g.mode == modeOAMScan || g.mode == modeDrawing {
+			return 0xff
+		}
+		return g.oam[a-0xfe00]
+
+	case a >= 0xfea0 && a < 0xff00:
+		// Unusable memory after OAM
+		return 0x00
+	}
+
+	switch a {
+	case 0xff40:
+		return g.lcdc
+	case 0xff41:
+		return g.stat
+	case 0xff42:
+		return g.scy
+	case 0xff43:
+		return g.scx
+	case 0xff44:
+		return g.ly
+	case 0xff45:
+		return g.lyc
+	case 0xff46:
+		return g.dma
+	case 0xff47:
+		return g.bgp
+	case 0xff48:
+		return g.obp0
+	case 0xff49:
+		return g.obp1
+	case 0xff4a:
+		return g.wy
+	case 0xff4b:
+		return g.wx
+	case 0xff4f:
+		return 0xfe | g.vbk
+	case 0xff68:
+		return g.bcps | 0x40
+	case 0xff69:
+		return g.readPalette(&g.bgPalettes, g.bcps)
+	case 0xff6a:
+		return g.ocps | 0x40
+	case 0xff6b:
+		return g.readPalette(&g.objPalettes, g.ocps)
+	}
+	return 0xff
+}
+
+// Write implements mmu.ReadWriter, see Read
+func (g *GPU) Write(a uint16, v uint8) {
+	switch {
+	case a >= 0x8000 && a < 0xa000:
+		if g.mode != modeDrawing {
+			g.vram[g.vramOffset(a)] = v
+		}
+		return
+
+	case a >= 0xfe00 && a < 0xfea0:
+		if g.mode != modeOAMScan && g.mode != modeDrawing {
+			g.oam[a-0xfe00] = v
+		}
+		return
+
+	case a >= 0xfea0 && a < 0xff00:
+		return
+	}
+
+	switch a {
+	case 0xff40:
+		g.setLCDC(v)
+	case 0xff41:
+		// The mode and coincidence bits are read only
+		g.stat = g.stat&0x07 | v&0x78
+		g.updateStat()
+	case 0xff42:
+		g.scy = v
+	case 0xff43:
+		g.scx = v
+	case 0xff44:
+		// LY is read only
+	case 0xff45:
+		g.lyc = v
+		g.updateStat()
+	case 0xff46:
+		g.startDMA(v)
+	case 0xff47:
+		g.bgp = v
+	case 0xff48:
+		g.obp0 = v
+	case 0xff49:
+		g.obp1 = v
+	case 0xff4a:
+		g.wy = v
+	case 0xff4b:
+		g.wx = v
+	case 0xff4f:
+		g.vbk = v & 0x01
+	case 0xff68:
+		g.bcps = v & 0xbf
+	case 0xff69:
+		g.writePalette(&g.bgPalettes, &g.bcps, v)
+	case 0xff6a:
+		g.ocps = v & 0xbf
+	case 0xff6b:
+		g.writePalette(&g.objPalettes, &g.ocps, v)
+	}
+}
+
+// vramOffset returns the offset in vram of an address in the bank selected by VBK
+func (g *GPU) vramOffset(a uint16) uint16 {
+	return uint16(g.vbk)*0x2000 + a - 0x8000
+}
+
+// readPalette reads the byte of palette RAM selected by an index register such as BCPS
+func (g *GPU) readPalette(ram *[64]uint8, index uint8) uint8 {
+	if g.mode == modeDrawing {
+		return 0xff
+	}
+	return ram[index&0x3f]
+}
+
+// writePalette writes the byte of palette RAM selected by an index register, which is incremented when its bit 7 is
+// set.  The increment happens even if the write is ignored because the GPU is drawing.
+func (g *GPU) writePalette(ram *[64]uint8, index *uint8, v uint8) {
+	if g.mode != modeDrawing {
+		ram[*index&0x3f] = v
+	}
+	if *index&0x80 != 0 {
+		*index = 0x80 | (*index+1)&0x3f
+	}
+}
+
+// startDMA copies 160 bytes from v * 0x100 into OAM.  On hardware the copy takes 160 cycles during which the CPU can
+// only access HRAM, here it happens at once.  Sources of 0xe0 and above read work RAM, like echo RAM does.
+func (g *GPU) startDMA(v uint8) {
+	g.dma = v
+	if v >= 0xe0 {
+		v -= 0x20
+	}
+	source := uint16(v) << 8
+	for i := range g.oam {
+		g.oam[i] = g.ram.Read(source + uint16(i))
+	}
+}
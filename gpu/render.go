@@ -0,0 +1,280 @@
+package gpu
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	// Width and Height are the size of the screen in pixels
+	Width  = 160
+	Height = 144
+)
+
+// Renderer selects how the GPU draws the screen
+type Renderer int
+
+const (
+	// ScanlineRenderer draws each line in one go at the end of drawing, which always takes 172 dots.  It is fast but
+	// misses writes to the registers while a line is drawn.
+	ScanlineRenderer Renderer = iota
+	// FIFORenderer emulates the pixel fetcher and FIFOs dot by dot.  The length of drawing varies with the fine
+	// scroll, the window and sprites as on hardware, and writes to SCX, the palettes and LCDC while drawing take
+	// effect mid-line.
+	FIFORenderer
+)
+
+var rendererNames = map[Renderer]string{
+	ScanlineRenderer: "scanline",
+	FIFORenderer:     "fifo",
+}
+
+func (r Renderer) String() string {
+	if name, ok := rendererNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("Renderer(%d)", int(r))
+}
+
+// ParseRenderer returns the Renderer for a name such as "scanline" or "fifo"
+func ParseRenderer(name string) (Renderer, error) {
+	for r, n := range rendererNames {
+		if n == name {
+			return r, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown renderer %q", name)
+}
+
+// Frame holds the color of every pixel on the screen
+type Frame [Height][Width]Color
+
+// Image returns the frame as an image, with each pixel scaled up to a square of scale by scale pixels
+func (f *Frame) Image(scale int) *image.RGBA {
+	return f.CorrectedImage(scale, RawColors)
+}
+
+// CorrectedImage returns the frame as an image like Image, with its colors corrected by the curve c
+func (f *Frame) CorrectedImage(scale int, c Correction) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+	img := image.NewRGBA(image.Rect(0, 0, Width*scale, Height*scale))
+	for y := 0; y < Height*scale; y++ {
+		for x := 0; x < Width*scale; x++ {
+			r, g, b := c.RGB8(f[y/scale][x/scale])
+			img.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: 0xff})
+		}
+	}
+	return img
+}
+
+// Frame returns the last completed frame, it is replaced at the start of every VBlank
+func (g *GPU) Frame() *Frame {
+	return g.front
+}
+
+// swap makes the frame that was just rendered visible, and restarts the window for the next frame
+func (g *GPU) swap() {
+	g.front, g.back = g.back, g.front
+	g.windowLine = 0
+}
+
+// blank fills the front frame with the color the screen has while the LCD is off
+func (g *GPU) blank() {
+	white := RGB(31, 31, 31)
+	if !g.color {
+		white = g.dmgColors[layerBG][0]
+	}
+	for y := range g.front {
+		for x := range g.front[y] {
+			g.front[y][x] = white
+		}
+	}
+}
+
+// bgPixel is a pixel of the background or window before its palette is applied
+type bgPixel struct {
+	color uint8
+	// attributes are the CGB tile attributes from VRAM bank 1
+	attributes uint8
+}
+
+// CGB background tile attribute bits
+const (
+	bgAttrPalette  = 0x07
+	bgAttrBank     = 0x08
+	bgAttrXFlip    = 0x20
+	bgAttrYFlip    = 0x40
+	bgAttrPriority = 0x80
+)
+
+// renderLine draws the current line into the back frame
+func (g *GPU) renderLine() {
+	var bg [Width]bgPixel
+	var obj [Width]objPixel
+	window := g.renderBackground(&bg)
+	g.renderSprites(&obj)
+
+	for x := 0; x < Width; x++ {
+		g.drawPixel(x, bg[x], x >= window, obj[x])
+	}
+}
+
+// renderBackground fetches the background and window pixels of the current line, it returns the column the window
+// starts at or Width if it isn't drawn
+func (g *GPU) renderBackground(bg *[Width]bgPixel) int {
+	for x := 0; x < Width; x++ {
+		bg[x] = g.backgroundPixel(x)
+	}
+
+	// Window, which is drawn over the background from WX-7 onwards once LY has reached WY
+	if !g.windowVisible() {
+		return Width
+	}
+	windowMap := uint16(0x9800)
+	if g.lcdc&lcdcWindowMap != 0 {
+		windowMap = 0x9c00
+	}
+	for x := int(g.wx) - 7; x < Width; x++ {
+		if x < 0 {
+			continue
+		}
+		bg[x] = g.mapPixel(windowMap, uint8(x-int(g.wx)+7), uint8(g.windowLine))
+	}
+	g.windowLine++
+	return int(g.wx) - 7
+}
+
+// backgroundPixel fetches the background pixel at column x of the current line
+func (g *GPU) backgroundPixel(x int) bgPixel {
+	bgMap := uint16(0x9800)
+	if g.lcdc&lcdcBGMap != 0 {
+		bgMap = 0x9c00
+	}
+	return g.mapPixel(bgMap, uint8(x)+g.scx, g.ly+g.scy)
+}
+
+// windowVisible reports if the window is drawn on the current line.  On the DMG clearing bit 0 of LCDC also hides the
+// window.
+func (g *GPU) windowVisible() bool {
+	if !g.color && g.lcdc&lcdcBGEnable == 0 {
+		return false
+	}
+	return g.lcdc&lcdcWindowEnable != 0 && g.ly >= g.wy && g.wx <= 166
+}
+
+// mix returns the color of a pixel from the background and sprite pixels at its position
+func (g *GPU) mix(bg bgPixel, obj objPixel) Color {
+	if g.objOnTop(bg, obj) {
+		return g.objColor(obj)
+	}
+	// On the DMG clearing bit 0 of LCDC blanks the background
+	if !g.color && g.lcdc&lcdcBGEnable == 0 {
+		bg.color = 0
+	}
+	return g.bgColor(bg)
+}
+
+// objOnTop reports if the sprite pixel is drawn over the background pixel at its position
+func (g *GPU) objOnTop(bg bgPixel, obj objPixel) bool {
+	if obj.color == 0 || g.lcdc&lcdcOBJEnable == 0 {
+		return false
+	}
+
+	if !g.color {
+		// On the DMG clearing bit 0 of LCDC blanks the background, so sprites with priority are drawn over it
+		return obj.attributes&attrPriority == 0 || bg.color == 0 || g.lcdc&lcdcBGEnable == 0
+	}
+
+	// On the CGB clearing bit 0 of LCDC takes priority away from the background, sprites are then always on top.
+	// Otherwise a sprite is hidden behind a visible background pixel if either has its priority bit set.
+	return g.lcdc&lcdcBGEnable == 0 || bg.color == 0 ||
+		(obj.attributes&attrPriority == 0 && bg.attributes&bgAttrPriority == 0)
+}
+
+// bgColor applies the palette to a background or window pixel, BGP outside of CGB mode and the palette from the tile
+// attributes in it
+func (g *GPU) bgColor(p bgPixel) Color {
+	if !g.color {
+		return g.dmgColors[layerBG][g.shade(g.bgp, p.color)]
+	}
+	return paletteColor(&g.bgPalettes, p.attributes&bgAttrPalette, p.color)
+}
+
+// objColor applies the palette to a sprite pixel, OBP0 or OBP1 outside of CGB mode and one of the 8 OBJ palettes in it
+func (g *GPU) objColor(p objPixel) Color {
+	if g.color {
+		return paletteColor(&g.objPalettes, p.attributes&attrCGBPalette, p.color)
+	}
+	if p.attributes&attrPalette != 0 {
+		return g.dmgColors[layerOBJ1][g.shade(g.obp1, p.color)]
+	}
+	return g.dmgColors[layerOBJ0][g.shade(g.obp0, p.color)]
+}
+
+// mapPixel returns the pixel at x, y in the 256x256 pixel tile map starting at base
+func (g *GPU) mapPixel(base uint16, x, y uint8) bgPixel {
+	p := bgPixel{attributes: g.mapAttributes(base, x/8, y/8)}
+	tile := g.vram[base-0x8000+uint16(y/8)*32+uint16(x/8)]
+	p.color = g.tilePixel(g.bgTileAddress(tile, p.attributes), g.flipX(x%8, p.attributes), g.flipY(y%8, p.attributes))
+	return p
+}
+
+// mapAttributes returns the CGB attributes of the tile at column x and row y of a tile map, they are stored at the same
+// position in VRAM bank 1.  Outside of CGB mode there are no attributes.
+func (g *GPU) mapAttributes(base uint16, x, y uint8) uint8 {
+	if !g.color {
+		return 0
+	}
+	return g.vram[0x2000+base-0x8000+uint16(y)*32+uint16(x)]
+}
+
+// bgTileAddress returns the address of a background or window tile, taking the VRAM bank from its attributes
+func (g *GPU) bgTileAddress(tile, attributes uint8) uint16 {
+	address := g.tileAddress(tile)
+	if attributes&bgAttrBank != 0 {
+		address += 0x2000
+	}
+	return address
+}
+
+// flipX and flipY apply the flip attributes of a background tile to a pixel position within the tile
+func (g *GPU) flipX(x, attributes uint8) uint8 {
+	if attributes&bgAttrXFlip != 0 {
+		return 7 - x
+	}
+	return x
+}
+
+func (g *GPU) flipY(y, attributes uint8) uint8 {
+	if attributes&bgAttrYFlip != 0 {
+		return 7 - y
+	}
+	return y
+}
+
+// tileAddress returns the VRAM address of a background or window tile.  LCDC bit 4 selects between tiles 0 - 255 at
+// 0x8000, and tiles -128 - 127 around 0x9000.
+func (g *GPU) tileAddress(tile uint8) uint16 {
+	if g.lcdc&lcdcTileData != 0 {
+		return 0x8000 + uint16(tile)*16
+	}
+	return uint16(0x9000 + int(int8(tile))*16)
+}
+
+// tilePixel returns the color index of a pixel in the tile at address, tiles in VRAM bank 1 are addressed as if bank 1
+// followed bank 0.  Each row of a tile is two bytes, the first holds the low bit of each pixel and the second the high
+// bit, with the leftmost pixel in bit 7.
+func (g *GPU) tilePixel(address uint16, x, y uint8) uint8 {
+	row := address - 0x8000 + uint16(y)*2
+	lo, hi := g.vram[row], g.vram[row+1]
+	bit := 7 - x
+	return (hi>>bit&1)<<1 | lo>>bit&1
+}
+
+// shade maps a color index through a palette register such as BGP
+func (g *GPU) shade(palette, color uint8) uint8 {
+	return palette >> (color * 2) & 0x03
+}
@@ -0,0 +1,159 @@
+package gpu
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/mmu"
+)
+
+// TestScenes renders static scenes of objects, the background and the window: object priority by X and OAM index, the
+// 10 object limit, flipping, 8x16 objects, palettes, object to background priority, the window and the map and tile
+// data selects.  Each frame is compared with a reference in testdata, drawn by hand with one character per shade.
+// This is not dmg-acid2: the ROM cannot run until the CPU implements its instructions, so the renderers have not been
+// checked against its reference image.
+func TestScenes(t *testing.T) {
+	// setup fills VRAM and the registers shared by both scenes
+	setup := func(g *GPU) {
+		// tile writes a tile with every row set to the same low and high plane
+		tile := func(a uint16, low, high uint8) {
+			for i := uint16(0); i < 16; i += 2 {
+				g.Write(a+i, low)
+				g.Write(a+i+1, high)
+			}
+		}
+
+		// Object tiles, 1 is solid color 3, 2 solid color 2, 6 solid color 1 and 7 solid color 2.  Tile 4 is an L of
+		// color 3, half a row at the top and a column on the left.  Tile 1 is also what the background would show
+		// if it used the wrong tile data area.
+		tile(0x8010, 0xff, 0xff)
+		tile(0x8020, 0x00, 0xff)
+		tile(0x8040, 0x80, 0x80)
+		g.Write(0x8040, 0xf0)
+		g.Write(0x8041, 0xf0)
+		tile(0x8060, 0xff, 0x00)
+		tile(0x8070, 0x00, 0xff)
+
+		// Background and window tiles in the signed area, 1 and 2 are solid color 1, 3 is solid color 3 and 0x81 is
+		// solid color 2
+		tile(0x9010, 0xff, 0x00)
+		tile(0x9020, 0xff, 0x00)
+		tile(0x9030, 0xff, 0xff)
+		tile(0x8810, 0x00, 0xff)
+
+		// The background uses the map at 0x9c00
+		g.Write(0x9c02, 0x01)
+		g.Write(0x9c03, 0x81)
+		g.Write(0x9c21, 0x81)
+
+		// The window uses the map at 0x9800, its first row is tile 3 and the rest tile 2
+		for a := uint16(0x9800); a < 0x9c00; a++ {
+			g.Write(a, 0x02)
+		}
+		for a := uint16(0x9800); a < 0x9820; a++ {
+			g.Write(a, 0x03)
+		}
+		g.Write(0xff4a, 104)
+		g.Write(0xff4b, 96+7)
+
+		// OBP1 maps color 3 to shade 1, so that it can be told apart from OBP0
+		g.Write(0xff47, 0xe4)
+		g.Write(0xff48, 0xe4)
+		g.Write(0xff49, 0x6c)
+	}
+
+	var tests = []struct {
+		name      string
+		reference string
+		lcdc      uint8
+		oam       []uint8
+	}{
+		{
+			name:      "Objects",
+			reference: "scene-objects.txt",
+			lcdc:      lcdcEnable | lcdcWindowEnable | lcdcBGMap | lcdcOBJEnable | lcdcBGEnable,
+			oam: []uint8{
+				// Y, X, tile, attributes
+				// A line of L shapes: behind the background, plain, X flipped, Y flipped and both with OBP1
+				16, 8 + 16, 4, attrPriority,
+				16, 8 + 32, 4, 0,
+				16, 8 + 40, 4, attrXFlip,
+				16, 8 + 48, 4, attrYFlip,
+				16, 8 + 56, 4, attrXFlip | attrYFlip | attrPalette,
+				// Behind the background, then the smaller X wins over the OAM index, then the OAM index wins at the
+				// same X and a transparent pixel shows the object below
+				24, 8 + 4, 1, attrPriority,
+				24, 8 + 24, 1, 0,
+				24, 8 + 20, 2, 0,
+				24, 8 + 40, 1, 0,
+				24, 8 + 40, 2, 0,
+				24, 8 + 56, 4, 0,
+				24, 8 + 60, 2, 0,
+				// Eleven objects on a line, the first is hidden at X 0 and the last is over the limit
+				32, 0, 2, 0,
+				32, 8 + 0, 2, 0,
+				32, 8 + 8, 2, 0,
+				32, 8 + 16, 2, 0,
+				32, 8 + 24, 2, 0,
+				32, 8 + 32, 2, 0,
+				32, 8 + 40, 2, 0,
+				32, 8 + 48, 2, 0,
+				32, 8 + 56, 2, 0,
+				32, 8 + 64, 2, 0,
+				32, 8 + 72, 2, 0,
+				// Behind the window
+				16 + 108, 8 + 92, 1, attrPriority | attrPalette,
+			},
+		},
+		{
+			name:      "TallObjects",
+			reference: "scene-tall-objects.txt",
+			lcdc:      lcdcEnable | lcdcWindowEnable | lcdcBGMap | lcdcOBJSize | lcdcOBJEnable,
+			oam: []uint8{
+				// The lowest bit of the tile is ignored, Y flipping swaps the two tiles and a disabled background
+				// cannot hide an object
+				16, 8, 7, 0,
+				16, 8 + 16, 6, attrYFlip,
+				16, 8 + 32, 4, attrPriority,
+			},
+		},
+	}
+
+	for _, renderer := range []Renderer{ScanlineRenderer, FIFORenderer} {
+		for _, test := range tests {
+			t.Run(renderer.String()+"/"+test.name, func(t *testing.T) {
+				reference, err := ioutil.ReadFile(filepath.Join("testdata", test.reference))
+				require.NoError(t, err)
+
+				g := New(make(mmu.RAM, 0x10000), false)
+				g.SetRenderer(renderer)
+				setup(g)
+				copy(g.oam[:], test.oam)
+				g.Write(0xff40, test.lcdc)
+
+				require.True(t, g.Next(visibleLines*dotsPerLine))
+				require.Equal(t, string(reference), frameText(g.Frame()))
+			})
+		}
+	}
+}
+
+// frameText draws a frame with one character per shade, from lightest to darkest ".-+#"
+func frameText(f *Frame) string {
+	b := &strings.Builder{}
+	for _, line := range f {
+		for _, c := range line {
+			for shade, gray := range grays {
+				if c == gray {
+					b.WriteByte(".-+#"[shade])
+				}
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package gpu
+
+import "sort"
+
+// Sprite attribute bits, the palette and bank bits are only used in CGB mode and attrPalette only outside of it
+const (
+	attrCGBPalette = 0x07
+	attrBank       = 0x08
+	attrPalette    = 0x10
+	attrXFlip      = 0x20
+	attrYFlip      = 0x40
+	attrPriority   = 0x80
+)
+
+// spritesPerLine is the number of sprites the OAM scan selects for each line, further sprites are not drawn
+const spritesPerLine = 10
+
+// sprite is an OAM entry that has been selected for the current line
+type sprite struct {
+	index      int
+	x, y       int
+	tile       uint8
+	attributes uint8
+}
+
+// spriteHeight returns 8 or 16 depending on the sprite size selected in LCDC
+func (g *GPU) spriteHeight() int {
+	if g.lcdc&lcdcOBJSize != 0 {
+		return 16
+	}
+	return 8
+}
+
+// scanOAM returns the sprites on the current line ordered by X coordinate, which is the order they are fetched in.
+// Only the first 10 sprites in OAM that overlap the line are selected, even if some are off screen.
+func (g *GPU) scanOAM() []sprite {
+	height := g.spriteHeight()
+	sprites := make([]sprite, 0, spritesPerLine)
+
+	for i := 0; i < len(g.oam) && len(sprites) < spritesPerLine; i += 4 {
+		s := sprite{
+			index:      i / 4,
+			y:          int(g.oam[i]) - 16,
+			x:          int(g.oam[i+1]) - 8,
+			tile:       g.oam[i+2],
+			attributes: g.oam[i+3],
+		}
+		if int(g.ly) >= s.y && int(g.ly) < s.y+height {
+			sprites = append(sprites, s)
+		}
+	}
+
+	// On the DMG this is also the priority of the sprites, with the one earlier in OAM first when they are equal
+	sort.SliceStable(sprites, func(i, j int) bool {
+		return sprites[i].x < sprites[j].x
+	})
+	return sprites
+}
+
+// objPixel is a sprite pixel before its palette is applied
+type objPixel struct {
+	color      uint8
+	attributes uint8
+	// index is the position of the sprite in OAM
+	index int
+}
+
+// wins reports if a pixel of the sprite s takes priority over the sprite pixel p at the same position, assuming the
+// sprites are visited in the order returned by scanOAM.  On the DMG the first sprite wins, on the CGB the sprite that
+// comes first in OAM.
+func (g *GPU) wins(s sprite, p objPixel) bool {
+	return p.color == 0 || (g.color && s.index < p.index)
+}
+
+// renderSprites fetches the sprite pixels of the current line
+func (g *GPU) renderSprites(obj *[Width]objPixel) {
+	if g.lcdc&lcdcOBJEnable == 0 {
+		return
+	}
+
+	height := g.spriteHeight()
+	for _, s := range g.scanOAM() {
+		for x := s.x; x < s.x+8; x++ {
+			if x < 0 || x >= Width || !g.wins(s, obj[x]) {
+				continue
+			}
+
+			// A transparent pixel lets a sprite with lower priority show through
+			if color := g.spritePixel(s, height, x); color != 0 {
+				obj[x] = objPixel{color: color, attributes: s.attributes, index: s.index}
+			}
+		}
+	}
+}
+
+// spritePixel returns the color index of the sprite at screen column x on the current line
+func (g *GPU) spritePixel(s sprite, height int, x int) uint8 {
+	return g.spriteTilePixel(s, height, x-s.x, int(g.ly)-s.y)
+}
+
+// spriteTilePixel returns the color index of the pixel at x, y within a sprite, applying its flips
+func (g *GPU) spriteTilePixel(s sprite, height int, x, y int) uint8 {
+	if s.attributes&attrXFlip != 0 {
+		x = 7 - x
+	}
+	if s.attributes&attrYFlip != 0 {
+		y = height - 1 - y
+	}
+
+	tile := s.tile
+	if height == 16 {
+		// 8x16 sprites ignore the lowest bit of the tile index, the bottom half is the next tile
+		tile &= 0xfe
+	}
+	address := 0x8000 + uint16(tile)*16
+	if g.color && s.attributes&attrBank != 0 {
+		address += 0x2000
+	}
+	return g.tilePixel(address, uint8(x), uint8(y))
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/borgstrom/ebgb/emulator"
+)
+
+// Exit codes of a headless run.  exitTimeout is used when a stop condition was given but not met before --frames ran
+// out, exitInterrupted when the run was stopped by a signal.
+const (
+	exitPass        = 0
+	exitFail        = 1
+	exitTimeout     = 2
+	exitInterrupted = 3
+)
+
+// headless runs the emulator without a window for a number of frames or until a stop condition is met
+type headless struct {
+	frames       int
+	screenshot   string
+	screenshotAt map[int]bool
+
+	// until stops the run once the byte at untilAddress is untilValue, it is only used if hasUntil is set
+	hasUntil     bool
+	untilAddress uint16
+	untilValue   uint8
+
+	// exitAddress is the address of the exit code, it is only used if hasExitAddress is set
+	hasExitAddress bool
+	exitAddress    uint16
+
+	serialPass []byte
+	serialFail []byte
+	serial     bytes.Buffer
+}
+
+// newHeadless validates the headless command line options
+func newHeadless(frames int, screenshot, screenshotAt, until, exitAddress, serialPass, serialFail string) (*headless, error) {
+	h := &headless{
+		frames:       frames,
+		screenshot:   screenshot,
+		screenshotAt: map[int]bool{},
+		serialPass:   []byte(serialPass),
+		serialFail:   []byte(serialFail),
+	}
+
+	if frames < 0 {
+		return nil, fmt.Errorf("--frames must not be negative")
+	}
+
+	if screenshotAt != "" {
+		if screenshot == "" {
+			return nil, errors.New("--screenshot-at needs --screenshot to name the files")
+		}
+		for _, f := range strings.Split(screenshotAt, ",") {
+			frame, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || frame < 1 {
+				return nil, fmt.Errorf("invalid frame %q in --screenshot-at", f)
+			}
+			h.screenshotAt[frame] = true
+		}
+	}
+
+	if until != "" {
+		parts := strings.SplitN(until, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--until must be ADDR=VALUE, not %q", until)
+		}
+		a, err := strconv.ParseUint(parts[0], 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in --until: %w", err)
+		}
+		v, err := strconv.ParseUint(parts[1], 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in --until: %w", err)
+		}
+		h.hasUntil, h.untilAddress, h.untilValue = true, uint16(a), uint8(v)
+	}
+
+	if exitAddress != "" {
+		a, err := strconv.ParseUint(exitAddress, 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address in --exit-code: %w", err)
+		}
+		h.hasExitAddress, h.exitAddress = true, uint16(a)
+	}
+
+	if frames == 0 && !h.hasUntil && !h.hasSerial() {
+		return nil, errors.New("--frames, --until, --serial-pass or --serial-fail is needed to end the run")
+	}
+	return h, nil
+}
+
+// hasSerial reports if the run is stopped by the serial output
+func (h *headless) hasSerial() bool {
+	return len(h.serialPass) > 0 || len(h.serialFail) > 0
+}
+
+// run runs the emulator and returns the exit code.  The serial output is copied to stdout.
+func (h *headless) run(ctx context.Context, e *emulator.Emulator) int {
+	e.SetSerialOutput(io.MultiWriter(os.Stdout, &h.serial))
+	defer e.SetSerialOutput(nil)
+
+	code, untilMet := -1, false
+	for frame := 1; h.frames == 0 || frame <= h.frames; frame++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		e.RunFrame()
+		if h.screenshotAt[frame] {
+			h.save(e, screenshotPath(h.screenshot, frame))
+		}
+
+		if len(h.serialPass) > 0 && bytes.Contains(h.serial.Bytes(), h.serialPass) {
+			code = exitPass
+			break
+		}
+		if len(h.serialFail) > 0 && bytes.Contains(h.serial.Bytes(), h.serialFail) {
+			code = exitFail
+			break
+		}
+		if h.hasUntil && e.Read(h.untilAddress) == h.untilValue {
+			untilMet = true
+			break
+		}
+	}
+
+	if h.screenshot != "" {
+		h.save(e, h.screenshot)
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		log.Printf("Interrupted after %d frames", e.Frames())
+		return exitInterrupted
+	case code >= 0:
+		return code
+	case (h.hasUntil || h.hasSerial()) && !untilMet:
+		log.Printf("No stop condition was met after %d frames", e.Frames())
+		return exitTimeout
+	case h.hasExitAddress:
+		return int(e.Read(h.exitAddress))
+	}
+	return exitPass
+}
+
+// save writes a frame to a PNG file, failures are logged since they shouldn't change the result of the run
+func (h *headless) save(e *emulator.Emulator, path string) {
+	if err := e.SaveScreenshot(path); err != nil {
+		log.Printf("Failed to save screenshot %s: %s", path, err)
+	}
+}
+
+// screenshotPath adds the frame number to a screenshot path, out.png becomes out-120.png
+func screenshotPath(path string, frame int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), frame, ext)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/emulator"
+)
+
+func TestHeadless(t *testing.T) {
+	// newEmulator starts a ROM that stops the CPU right away, RAM is filled with zeros
+	newEmulator := func(t *testing.T) *emulator.Emulator {
+		rom := make([]byte, 0x8000)
+		rom[0x0100] = 0x10
+		e, err := emulator.New(bytes.NewReader(rom), emulator.Options{SkipBootROM: true})
+		require.NoError(t, err)
+		return e
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Frames",
+			test: func(t *testing.T) {
+				h, err := newHeadless(3, "", "", "", "", "", "")
+				require.NoError(t, err)
+				e := newEmulator(t)
+				require.Equal(t, exitPass, h.run(context.Background(), e))
+				require.EqualValues(t, 3, e.Frames())
+			},
+		},
+		{
+			name: "Until",
+			test: func(t *testing.T) {
+				h, err := newHeadless(3, "", "", "0xc000=0x00", "", "", "")
+				require.NoError(t, err)
+				e := newEmulator(t)
+				require.Equal(t, exitPass, h.run(context.Background(), e))
+				require.EqualValues(t, 1, e.Frames())
+
+				// The exit code is read once the condition is met
+				h, err = newHeadless(3, "", "", "0xc000=0x00", "0xff47", "", "")
+				require.NoError(t, err)
+				require.Equal(t, 0xfc, h.run(context.Background(), newEmulator(t)))
+			},
+		},
+		{
+			name: "UntilNotMet",
+			test: func(t *testing.T) {
+				h, err := newHeadless(3, "", "", "0xc000=0x01", "0xff47", "", "")
+				require.NoError(t, err)
+				e := newEmulator(t)
+				require.Equal(t, exitTimeout, h.run(context.Background(), e))
+				require.EqualValues(t, 3, e.Frames())
+			},
+		},
+		{
+			name: "SerialNotMet",
+			test: func(t *testing.T) {
+				h, err := newHeadless(3, "", "", "", "", "Passed", "Failed")
+				require.NoError(t, err)
+				require.Equal(t, exitTimeout, h.run(context.Background(), newEmulator(t)))
+			},
+		},
+		{
+			name: "Interrupted",
+			test: func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				h, err := newHeadless(0, "", "", "0xc000=0x01", "", "", "")
+				require.NoError(t, err)
+				e := newEmulator(t)
+				require.Equal(t, exitInterrupted, h.run(ctx, e))
+				require.Zero(t, e.Frames())
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/borgstrom/ebgb/dat"
+	"github.com/borgstrom/ebgb/emulator"
+)
+
+// info implements the info command, which prints the decoded header of one or more ROMs
+func info(args []string) {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print one JSON object per ROM instead of text")
+	var datPaths stringsFlag
+	flags.Var(&datPaths, "dat", "No-Intro DAT file used to identify the ROMs, may be repeated")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatalf("Usage: %s info [--json] <rom> [<rom> ...]", os.Args[0])
+	}
+
+	d := dat.New()
+	for _, path := range datPaths {
+		if err := d.LoadFile(path); err != nil {
+			log.Fatalf("Failed to load %s: %s", path, err)
+		}
+	}
+
+	failed := false
+	for _, path := range flags.Args() {
+		if err := printInfo(path, d, *asJSON); err != nil {
+			log.Printf("Failed to read %s: %s", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func printInfo(path string, d *dat.DAT, asJSON bool) error {
+	rom, err := emulator.OpenROM(path)
+	if err != nil {
+		return err
+	}
+
+	cartridge, err := emulator.Load(bytes.NewReader(rom.Data), nil, emulator.Lenient)
+	if err != nil {
+		return err
+	}
+	i := cartridge.Info()
+	game, _ := d.Lookup(rom.Data)
+
+	if asJSON {
+		return json.NewEncoder(os.Stdout).Encode(struct {
+			Path string    `json:"path"`
+			Game *dat.Game `json:"game,omitempty"`
+			emulator.CartridgeInfo
+		}{path, game, i})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "File:\t%s\n", path)
+	if game != nil {
+		fmt.Fprintf(w, "Game:\t%s\n", game.Name)
+		fmt.Fprintf(w, "Region:\t%s\n", game.Region)
+		if game.Status != "" {
+			fmt.Fprintf(w, "Dump:\t%s\n", game.Status)
+		}
+	}
+	fmt.Fprintf(w, "Title:\t%s\n", i.Title)
+	if i.Manufacturer != "" {
+		fmt.Fprintf(w, "Manufacturer:\t%s\n", i.Manufacturer)
+	}
+	fmt.Fprintf(w, "CGB:\t%s (%#02x)\n", i.CGB, i.CGBFlag)
+	fmt.Fprintf(w, "SGB:\t%t\n", i.SGB)
+	fmt.Fprintf(w, "Type:\t%s (%#02x)\n", i.TypeName, i.Type)
+	fmt.Fprintf(w, "MBC:\t%s\n", i.MBC)
+	fmt.Fprintf(w, "ROM:\t%d KiB, %d banks\n", i.ROMSize/1024, i.ROMBanks)
+	fmt.Fprintf(w, "RAM:\t%d KiB, %d banks\n", i.RAMSize/1024, i.RAMBanks)
+	fmt.Fprintf(w, "Destination:\t%s\n", i.Destination)
+	fmt.Fprintf(w, "Old licensee:\t%s (%#02x)\n", i.OldLicensee, i.OldLicenseeCode)
+	if i.NewLicenseeCode != "" {
+		fmt.Fprintf(w, "New licensee:\t%s (%s)\n", i.NewLicensee, i.NewLicenseeCode)
+	}
+	fmt.Fprintf(w, "Version:\t%d\n", i.MaskROMVersion)
+	fmt.Fprintf(w, "Logo:\t%s\n", status(i.LogoValid))
+	fmt.Fprintf(w, "Header checksum:\t%s\n", status(i.HeaderChecksumValid))
+	fmt.Fprintf(w, "Global checksum:\t%s\n", status(i.GlobalChecksumValid))
+	fmt.Fprintf(w, "Size:\t%s\n", status(i.SizeValid))
+	for _, p := range i.Problems {
+		fmt.Fprintf(w, "Problem:\t%s\n", p)
+	}
+	fmt.Fprintln(w)
+	return w.Flush()
+}
+
+func status(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "BAD"
+}
@@ -1,31 +1,350 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"path"
+	"strings"
 	"syscall"
 
+	"github.com/borgstrom/ebgb/dat"
 	"github.com/borgstrom/ebgb/emulator"
+	"github.com/borgstrom/ebgb/frontend/filter"
+	"github.com/borgstrom/ebgb/gpu"
+	"github.com/borgstrom/ebgb/mmu"
+	"github.com/borgstrom/ebgb/record"
 )
 
 func main() {
-	if len(os.Args) != 2 {
-		log.Fatalf("Usage: %s <rom>", os.Args[0])
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "mkpatch":
+			mkpatch(os.Args[2:])
+			return
+		case "info":
+			info(os.Args[2:])
+			return
+		case "run":
+			run(os.Args[2:])
+			return
+		}
+	}
+
+	run(os.Args[1:])
+}
+
+// run implements the run command, which is also used when no command is given.  It runs a ROM in a window, or
+// without one for --headless.
+func run(args []string) {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	var (
+		configPath   = flags.String("config", emulator.DefaultConfigPath(), "Path of the config file")
+		model        = flags.String("model", "auto", "Hardware model to emulate: auto, dmg0, dmg, mgb, sgb, cgb or agb")
+		bootROM      = flags.String("bootrom", "", "Path of the boot ROM to run, overrides the config file")
+		skipBootROM  = flags.Bool("skip-bootrom", false, "Skip the boot ROM and start the cartridge directly")
+		ramFill      = flags.String("ram-fill", "zero", "Power-on RAM contents: zero, ones, pattern or random")
+		ramSeed      = flags.Int64("ram-seed", 0, "Seed for --ram-fill=random, a seed is picked and logged when 0")
+		patchPath    = flags.String("patch", "", "Path of an IPS, BPS or UPS patch, defaults to a patch next to the ROM")
+		strict       = flags.Bool("strict", false, "Refuse to load cartridges with a bad header, checksum or size")
+		paletteCombo = flags.String("cgb-palette", "none", "Button combo that picks the colors of DMG games on the CGB, such as up, left+a or down+b")
+		paletteName  = flags.String("palette", "", "DMG colors: dmg, pocket, light, contrast, a palette from the config file or a .pal, .gpl or .hex file")
+		hideLayers   = flags.String("hide", "", "Layers to hide: a comma separated list of bg, window and sprites")
+		overlayNames = flags.String("overlay", "", "Debug overlays to draw: a comma separated list of boxes, window and tint")
+		scale        = flags.Int("scale", 3, "Size of the window as a multiple of 160x144")
+		fullscreen   = flags.Bool("fullscreen", false, "Start in fullscreen")
+		filterName   = flags.String("filter", "nearest", "Video filter: nearest, scale2x, scale3x or lcd")
+		correction   = flags.String("color-correction", "auto", "CGB LCD color correction: auto, raw, cgb or agb, auto matches the model")
+		correctShots = flags.Bool("correct-screenshots", false, "Apply the color correction to screenshots too, following changes made with C")
+		blend        = flags.Bool("blend", false, "Blend each frame with the one before it, for games that flicker sprites")
+		rendererName = flags.String("renderer", "scanline", "GPU renderer: scanline, or fifo for accuracy with mid-line effects")
+		noWindow     = flags.Bool("headless", false, "Run without a window, the other headless options only apply in this mode")
+		frames       = flags.Int("frames", 0, "Number of frames to run headless, 0 runs until a stop condition is met")
+		screenshot   = flags.String("screenshot", "", "PNG file the last frame is saved to when running headless")
+		screenshotAt = flags.String("screenshot-at", "", "Frames to save screenshots at, such as 120,600, named after --screenshot with the frame number added")
+		until        = flags.String("until", "", "Stop running headless once memory matches ADDR=VALUE, such as 0xa000=0x00")
+		exitAddress  = flags.String("exit-code", "", "Address of the byte to use as the exit code when a headless run ends")
+		serialPass   = flags.String("serial-pass", "", "Stop running headless and exit with 0 once the serial output contains this text")
+		serialFail   = flags.String("serial-fail", "", "Stop running headless and exit with 1 once the serial output contains this text")
+		shotScale    = flags.Int("screenshot-scale", 1, "Scale screenshots up by this whole number")
+		recordPath   = flags.String("record", "", "Record every frame to a .png (APNG), .gif, or .y4m file with the audio in a .wav file next to it")
+		cheatCodes   stringsFlag
+		datPaths     stringsFlag
+	)
+	flags.Var(&cheatCodes, "cheat", "Game Genie or GameShark code to apply, may be repeated")
+	flags.Var(&datPaths, "dat", "No-Intro DAT file used to identify the ROM, may be repeated")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatalf("Usage: %s [run] [options] <rom|archive.zip[#name.gb]|rom.gz>", os.Args[0])
+	}
+
+	m, err := emulator.ParseModel(*model)
+	if err != nil {
+		log.Fatalf("Invalid model: %s", err)
+	}
+
+	fill, err := mmu.ParseFill(*ramFill)
+	if err != nil {
+		log.Fatalf("Invalid RAM fill: %s", err)
+	}
+
+	renderer, err := gpu.ParseRenderer(*rendererName)
+	if err != nil {
+		log.Fatalf("Invalid renderer: %s", err)
+	}
+
+	hidden, err := gpu.ParseLayers(*hideLayers)
+	if err != nil {
+		log.Fatalf("Invalid layers: %s", err)
+	}
+
+	overlays, err := gpu.ParseOverlays(*overlayNames)
+	if err != nil {
+		log.Fatalf("Invalid overlays: %s", err)
+	}
+
+	videoFilter, err := filter.ParseFilter(*filterName)
+	if err != nil {
+		log.Fatalf("Invalid filter: %s", err)
+	}
+
+	// The auto color correction depends on the model, which is only known once the cartridge is loaded
+	var colorCorrection gpu.Correction
+	if *correction != "auto" {
+		if colorCorrection, err = gpu.ParseCorrection(*correction); err != nil {
+			log.Fatalf("Invalid color correction: %s", err)
+		}
+	}
+
+	combo, err := emulator.ParsePaletteCombo(*paletteCombo)
+	if err != nil {
+		log.Fatalf("Invalid CGB palette: %s", err)
+	}
+
+	config, err := emulator.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config %s: %s", *configPath, err)
+	}
+
+	palettes, palette, err := loadPalettes(config, *paletteName)
+	if err != nil {
+		log.Fatalf("Failed to load palette: %s", err)
 	}
 
-	f, err := os.Open(os.Args[1])
+	rom, err := emulator.OpenROM(flags.Arg(0))
 	if err != nil {
-		log.Fatalf("Failed to read %s: %s", os.Args[1], err)
+		log.Fatalf("Failed to read %s: %s", flags.Arg(0), err)
+	}
+
+	// Identify the ROM so that it can be named by its canonical name and have per-game settings
+	title := ""
+	bases := []string{rom.BasePath()}
+	game, err := identify(rom, append(config.DATs, datPaths...))
+	if err != nil {
+		log.Fatalf("Failed to identify %s: %s", flags.Arg(0), err)
+	}
+	if game != nil {
+		log.Printf("Identified %s as %s (%s)", rom.Name, game.Name, game.Region)
+		if game.BadDump() {
+			log.Printf("Warning: %s is a known bad dump", game.Name)
+		}
+
+		// Files written for the ROM, such as saves and screenshots, use the canonical name
+		title = game.Name
+		bases = canonicalName(rom, game)
+		log.Printf("Files for %s are named after %s", flags.Arg(0), rom.BasePath())
+
+		settings := config.Games[game.Name]
+		if m == emulator.Auto {
+			m = settings.Model
+		}
+		// The codes are copied so that appending does not write into the config
+		cheatCodes = append(append([]string{}, settings.Cheats...), cheatCodes...)
+	}
+
+	// base is the path of every file written for the ROM, patches and cheats are also found under the name it was
+	// opened with
+	base := rom.BasePath()
+	if *patchPath == "" {
+		*patchPath = emulator.FindPatch(bases...)
 	}
-	defer f.Close()
 
 	ctx, cancel := ContextWithCancelAndSignals(context.Background())
 	defer cancel()
 
-	e := emulator.New(f)
-	e.Run(ctx)
+	policy := emulator.Lenient
+	if *strict {
+		policy = emulator.Strict
+	}
+
+	e, err := emulator.New(bytes.NewReader(rom.Data), emulator.Options{
+		Title:           title,
+		Patch:           *patchPath,
+		Policy:          policy,
+		Model:           m,
+		BootROM:         *bootROM,
+		BootROMs:        config.BootROMs,
+		SkipBootROM:     *skipBootROM,
+		RAMFill:         fill,
+		RAMSeed:         *ramSeed,
+		Renderer:        renderer,
+		Palette:         palette,
+		Palettes:        palettes,
+		PaletteCombo:    combo,
+		HiddenLayers:    hidden,
+		Overlays:        overlays,
+		BasePath:        base,
+		ScreenshotScale: *shotScale,
+	})
+	if err != nil {
+		log.Fatalf("Failed to start %s: %s", flags.Arg(0), err)
+	}
+	for _, warning := range e.Cartridge().Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+
+	// Cheats are read from a .cht file next to the ROM, followed by the codes from the command line
+	if cheatFile := findCheats(bases); cheatFile != "" {
+		if err := e.Cheats().LoadFile(cheatFile); err != nil {
+			log.Fatalf("Failed to load cheats from %s: %s", cheatFile, err)
+		}
+	}
+	for _, code := range cheatCodes {
+		if _, err := e.Cheats().Add(code, ""); err != nil {
+			log.Fatalf("Invalid cheat: %s", err)
+		}
+	}
+
+	if *correction == "auto" {
+		colorCorrection = e.LCDCorrection()
+	}
+	// The window keeps screenshots in step with the correction when it is cycled, this covers headless runs
+	if *correctShots {
+		e.SetScreenshotCorrection(colorCorrection)
+	}
+
+	var h *headless
+	if *noWindow {
+		h, err = newHeadless(*frames, *screenshot, *screenshotAt, *until, *exitAddress, *serialPass, *serialFail)
+		if err != nil {
+			log.Fatalf("Invalid headless options: %s", err)
+		}
+	}
+
+	stopRecording := func() {}
+	if *recordPath != "" {
+		r, err := record.Create(*recordPath)
+		if err != nil {
+			log.Fatalf("Failed to start recording: %s", err)
+		}
+		e.SetRecorder(r)
+		stopRecording = func() {
+			e.SetRecorder(nil)
+			if err := r.Close(); err != nil {
+				log.Printf("Failed to save recording %s: %s", *recordPath, err)
+				return
+			}
+			log.Printf("Saved %d frames to %s", r.Frames(), *recordPath)
+		}
+	}
+
+	if h != nil {
+		code := h.run(ctx, e)
+		stopRecording()
+		cancel()
+		os.Exit(code)
+	}
+
+	err = runWindow(ctx, e, *scale, *fullscreen, videoFilter, *blend, colorCorrection, *correctShots)
+	stopRecording()
+	if err != nil {
+		log.Fatalf("Failed to run %s: %s", flags.Arg(0), err)
+	}
+}
+
+// identify looks up the ROM in the DAT files, it returns nil if there are no DAT files or the ROM is not in them
+func identify(rom *emulator.ROMFile, paths []string) (*dat.Game, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	d := dat.New()
+	for _, path := range paths {
+		if err := d.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	game, _ := d.Lookup(rom.Data)
+	return game, nil
+}
+
+// canonicalName renames the ROM after the game it was identified as.  It returns the base paths that patches and
+// cheats are looked up under: first the ROM as it was opened, so that files kept next to it are still found, then the
+// canonical name.
+func canonicalName(rom *emulator.ROMFile, game *dat.Game) []string {
+	original := rom.BasePath()
+	rom.Name = game.Name + path.Ext(rom.Name)
+	if base := rom.BasePath(); base != original {
+		return []string{original, base}
+	}
+	return []string{original}
+}
+
+// findCheats returns the first .cht file that exists under the base paths, or an empty string if there is none
+func findCheats(bases []string) string {
+	for _, base := range bases {
+		if _, err := os.Stat(base + ".cht"); err == nil {
+			return base + ".cht"
+		}
+	}
+	return ""
+}
+
+// loadPalettes returns the presets followed by the palettes from the config file, and the palette to start with.  The
+// name selects a palette by name or is the path of a palette file, the palette from the config file is used if it is
+// empty.
+func loadPalettes(config *emulator.Config, name string) ([]emulator.Palette, emulator.Palette, error) {
+	palettes := append([]emulator.Palette{}, emulator.Presets...)
+	for _, path := range config.Palettes {
+		p, err := emulator.LoadPalette(path)
+		if err != nil {
+			return nil, emulator.Palette{}, err
+		}
+		palettes = append(palettes, p)
+	}
+
+	if name == "" {
+		name = config.Palette
+	}
+	if name == "" {
+		return palettes, palettes[0], nil
+	}
+	if p, ok := emulator.FindPalette(palettes, name); ok {
+		return palettes, p, nil
+	}
+
+	p, err := emulator.LoadPalette(name)
+	if err != nil {
+		return nil, emulator.Palette{}, err
+	}
+	return append(palettes, p), p, nil
+}
+
+// stringsFlag is a flag.Value that collects every occurrence of a repeated flag
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
 
 // ContextWithCancelAndSignals returns a context and a cancel function.  The context will
@@ -41,7 +360,7 @@ func ContextWithCancelAndSignals(parent context.Context) (context.Context, conte
 
 	go func() {
 		// Create a channel for receiving the signals
-		shutdown := make(chan os.Signal)
+		shutdown := make(chan os.Signal, 1)
 
 		// Bind the channel to the signals
 		signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
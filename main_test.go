@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/dat"
+	"github.com/borgstrom/ebgb/emulator"
+)
+
+func TestCanonicalName(t *testing.T) {
+	game := &dat.Game{Name: "Tetris (World) (Rev 1)"}
+
+	// open writes a ROM and the files next to it to a new directory, and opens the ROM
+	open := func(t *testing.T, name string, files ...string) (*emulator.ROMFile, string) {
+		dir := t.TempDir()
+		for _, f := range append(files, name) {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, f), []byte{0}, 0644))
+		}
+		rom, err := emulator.OpenROM(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return rom, dir
+	}
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "OriginalName",
+			test: func(t *testing.T) {
+				// A patch and cheats named after the file that was opened are found once the ROM is identified
+				rom, dir := open(t, "tetris.gb", "tetris.ips", "tetris.cht")
+				bases := canonicalName(rom, game)
+				require.Equal(t, "Tetris (World) (Rev 1).gb", rom.Name)
+				require.Equal(t, filepath.Join(dir, "Tetris (World) (Rev 1)"), rom.BasePath())
+				require.Equal(t, filepath.Join(dir, "tetris.ips"), emulator.FindPatch(bases...))
+				require.Equal(t, filepath.Join(dir, "tetris.cht"), findCheats(bases))
+			},
+		},
+		{
+			name: "CanonicalName",
+			test: func(t *testing.T) {
+				rom, dir := open(t, "tetris.gb", "Tetris (World) (Rev 1).bps", "Tetris (World) (Rev 1).cht")
+				bases := canonicalName(rom, game)
+				require.Equal(t, filepath.Join(dir, "Tetris (World) (Rev 1).bps"), emulator.FindPatch(bases...))
+				require.Equal(t, filepath.Join(dir, "Tetris (World) (Rev 1).cht"), findCheats(bases))
+			},
+		},
+		{
+			name: "OriginalFirst",
+			test: func(t *testing.T) {
+				rom, dir := open(t, "tetris.gb", "tetris.ips", "Tetris (World) (Rev 1).ips")
+				require.Equal(t, filepath.Join(dir, "tetris.ips"), emulator.FindPatch(canonicalName(rom, game)...))
+			},
+		},
+		{
+			name: "Missing",
+			test: func(t *testing.T) {
+				rom, _ := open(t, "Tetris (World) (Rev 1).gb")
+				bases := canonicalName(rom, game)
+				require.Len(t, bases, 1)
+				require.Empty(t, emulator.FindPatch(bases...))
+				require.Empty(t, findCheats(bases))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
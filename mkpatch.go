@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/borgstrom/ebgb/patch"
+)
+
+// mkpatch implements the mkpatch command, which writes a BPS patch that turns one ROM into another
+func mkpatch(args []string) {
+	if len(args) != 3 {
+		log.Fatalf("Usage: %s mkpatch <original> <modified> <patch.bps>", os.Args[0])
+	}
+
+	source, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		log.Fatalf("Failed to read %s: %s", args[0], err)
+	}
+
+	target, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		log.Fatalf("Failed to read %s: %s", args[1], err)
+	}
+
+	if err := ioutil.WriteFile(args[2], patch.CreateBPS(source, target), 0644); err != nil {
+		log.Fatalf("Failed to write %s: %s", args[2], err)
+	}
+}
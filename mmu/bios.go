@@ -1,5 +1,29 @@
 package mmu
 
+// BootROM holds the contents of a boot ROM, it is mapped over the start of the cartridge ROM until it is disabled by
+// writing to 0xff50.  The 256 byte boot ROMs of the DMG, MGB and SGB are mapped at 0x0000 - 0x00ff, the 2304 byte
+// boot ROMs of the CGB and AGB are additionally mapped at 0x0200 - 0x08ff, leaving the cartridge header at
+// 0x0100 - 0x01ff visible.
+type BootROM []uint8
+
+const (
+	// BootROMSize is the size of the DMG, MGB and SGB boot ROMs
+	BootROMSize = 0x100
+	// CGBBootROMSize is the size of the CGB and AGB boot ROMs
+	CGBBootROMSize = 0x900
+)
+
+// Maps reports if the boot ROM covers the given address
+func (b BootROM) Maps(a uint16) bool {
+	if a < 0x0100 {
+		return int(a) < len(b)
+	}
+	return a >= 0x0200 && int(a) < len(b)
+}
+
+// DMGBootROM is the boot ROM of the original Game Boy, it is used when no other boot ROM is provided
+var DMGBootROM = BootROM(bios[:])
+
 var bios = [...]uint8{
 	0x31, 0xFE, 0xFF, 0xAF, 0x21, 0xFF, 0x9F, 0x32, 0xCB, 0x7C, 0x20, 0xFB, 0x21, 0x26, 0xFF, 0x0E,
 	0x11, 0x3E, 0x80, 0x32, 0xE2, 0x0C, 0x3E, 0xF3, 0xE2, 0x32, 0x3E, 0x77, 0x77, 0x3E, 0xFC, 0xE0,
@@ -0,0 +1,81 @@
+package mmu
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Fill selects the contents of RAM at power-on.  Real hardware powers up with semi-random RAM contents that some
+// games and tests depend on, a Fill allows reproducing them deterministically.
+type Fill int
+
+const (
+	// FillZero clears RAM to 0x00
+	FillZero Fill = iota
+	// FillOnes sets RAM to 0xff
+	FillOnes
+	// FillPattern approximates the power-on pattern of the model
+	FillPattern
+	// FillRandom fills RAM from a seeded pseudo-random generator
+	FillRandom
+)
+
+var fillNames = map[Fill]string{
+	FillZero:    "zero",
+	FillOnes:    "ones",
+	FillPattern: "pattern",
+	FillRandom:  "random",
+}
+
+func (f Fill) String() string {
+	if name, ok := fillNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Fill(%d)", int(f))
+}
+
+// ParseFill returns the Fill for a name such as "zero" or "random"
+func ParseFill(name string) (Fill, error) {
+	for fill, n := range fillNames {
+		if n == name {
+			return fill, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown RAM fill %q", name)
+}
+
+// Fill sets the contents of the work, high and cartridge RAM.  The seed is only used by FillRandom.
+func (m *MMU) Fill(fill Fill, seed int64) {
+	rng := rand.New(rand.NewSource(seed))
+
+	for _, ram := range [][]uint8{m.eRAM[:], m.wRAM[:], m.zRAM[:]} {
+		for i := range ram {
+			switch fill {
+			case FillZero:
+				ram[i] = 0x00
+			case FillOnes:
+				ram[i] = 0xff
+			case FillPattern:
+				ram[i] = m.pattern(i)
+			case FillRandom:
+				ram[i] = uint8(rng.Intn(0x100))
+			}
+		}
+	}
+}
+
+// pattern returns the power-on value at offset i of a RAM.  The DMG powers up with alternating runs of 0x00 and 0xff
+// every 16 bytes, the CGB with runs of 8 bytes that are inverted every 512 bytes.  These are approximations, the
+// exact contents differ between units.
+func (m *MMU) pattern(i int) uint8 {
+	run, invert := 16, false
+	if m.cgb {
+		run, invert = 8, (i/512)%2 == 1
+	}
+
+	v := (i/run)%2 == 1
+	if v != invert {
+		return 0xff
+	}
+	return 0x00
+}
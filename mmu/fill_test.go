@@ -0,0 +1,54 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFill(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "Ones",
+			test: func(t *testing.T) {
+				m := New(nil, nil, false)
+				m.Fill(FillOnes, 0)
+				require.EqualValues(t, 0xff, m.Read(0xc000))
+				require.EqualValues(t, 0xff, m.Read(0xff80))
+			},
+		},
+		{
+			name: "Pattern",
+			test: func(t *testing.T) {
+				m := New(nil, nil, false)
+				m.Fill(FillPattern, 0)
+				require.EqualValues(t, 0x00, m.Read(0xc00f))
+				require.EqualValues(t, 0xff, m.Read(0xc010))
+
+				m = New(nil, nil, true)
+				m.Fill(FillPattern, 0)
+				require.EqualValues(t, 0xff, m.Read(0xc008))
+				require.EqualValues(t, 0x00, m.Read(0xc208))
+			},
+		},
+		{
+			name: "RandomIsSeeded",
+			test: func(t *testing.T) {
+				a, b := New(nil, nil, false), New(nil, nil, false)
+				a.Fill(FillRandom, 42)
+				b.Fill(FillRandom, 42)
+				require.Equal(t, a.wRAM, b.wRAM)
+
+				b.Fill(FillRandom, 43)
+				require.NotEqual(t, a.wRAM, b.wRAM)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
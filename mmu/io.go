@@ -0,0 +1,77 @@
+package mmu
+
+// ioMask holds the bits of each I/O register (0xff00 - 0xff7f) that are unused and always read back as set
+// See: https://gbdev.io/pandocs/Hardware_Reg_List.html
+var ioMask = [0x80]uint8{
+	0x00: 0xc0, // P1
+	0x02: 0x7e, // SC
+	0x07: 0xf8, // TAC
+	0x0f: 0xe0, // IF
+
+	0x10: 0x80, // NR10
+	0x11: 0x3f, // NR11
+	0x13: 0xff, // NR13
+	0x14: 0xbf, // NR14
+	0x15: 0xff, // unused
+	0x16: 0x3f, // NR21
+	0x18: 0xff, // NR23
+	0x19: 0xbf, // NR24
+	0x1a: 0x7f, // NR30
+	0x1b: 0xff, // NR31
+	0x1c: 0x9f, // NR32
+	0x1d: 0xff, // NR33
+	0x1e: 0xbf, // NR34
+	0x1f: 0xff, // unused
+	0x20: 0xff, // NR41
+	0x23: 0xbf, // NR44
+	0x26: 0x70, // NR52
+
+	0x41: 0x80, // STAT
+
+	0x4d: 0x7e, // KEY1
+	0x4f: 0xfe, // VBK
+	0x56: 0x3c, // RP
+	0x70: 0xf8, // SVBK
+}
+
+// cgbIOMask holds the differences from ioMask on the CGB
+var cgbIOMask = map[uint16]uint8{
+	0xff02: 0x7c, // SC, bit 1 selects the clock speed
+}
+
+// cgbOnly reports if the I/O register only exists on the CGB, on other models it reads back as 0xff and ignores writes
+func cgbOnly(a uint16) bool {
+	switch {
+	case a >= 0xff4d && a <= 0xff4f:
+		// KEY1, VBK
+		return true
+	case a >= 0xff51 && a <= 0xff56:
+		// HDMA1 - HDMA5, RP
+		return true
+	case a >= 0xff68 && a <= 0xff6c:
+		// BCPS, BCPD, OCPS, OCPD, OPRI
+		return true
+	case a == 0xff70:
+		// SVBK
+		return true
+	case a >= 0xff72 && a <= 0xff77:
+		// Undocumented registers
+		return true
+	}
+	return false
+}
+
+// isIO reports if the address is an I/O register
+func isIO(a uint16) bool {
+	return a >= 0xff00 && a < 0xff80
+}
+
+// readMask returns the bits that always read back as set for the I/O register
+func (m *MMU) readMask(a uint16) uint8 {
+	if m.cgb {
+		if mask, ok := cgbIOMask[a]; ok {
+			return mask
+		}
+	}
+	return ioMask[a-0xff00]
+}
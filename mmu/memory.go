@@ -6,6 +6,11 @@ type ReadWriter interface {
 	Write(a uint16, v uint8)
 }
 
+// Interceptor is used by the MMU to replace the values returned by reads, for example to apply cheats
+type Interceptor interface {
+	Intercept(a uint16, v uint8) uint8
+}
+
 // RAM is a slice backed container that can be used as a ReadWriter
 type RAM []uint8
 
@@ -5,54 +5,182 @@ type MMU struct {
 	eRAM [8192]uint8
 	wRAM [32768]uint8
 	zRAM [127]uint8
+	ie   uint8
+	ifr  uint8
 
+	boot        BootROM
 	biosEnabled bool
+	cgb         bool
+
+	mappings    []mapping
+	interceptor Interceptor
+}
+
+// mapping routes an inclusive address range to a ReadWriter owned by another component
+type mapping struct {
+	start uint16
+	end   uint16
+	rw    ReadWriter
 }
 
-func New(rom []uint8) *MMU {
+// New returns a MMU for the given cartridge ROM.  If boot is nil the MMU starts with the boot ROM already disabled.
+// When cgb is false the registers that only exist on the Game Boy Color are not accessible.
+func New(rom []uint8, boot BootROM, cgb bool) *MMU {
 	return &MMU{
 		rom:         rom,
-		biosEnabled: true,
+		boot:        boot,
+		biosEnabled: boot != nil,
+		cgb:         cgb,
 	}
 }
 
+// Reset performs a soft reset, the boot ROM is enabled again and all mappings and the interceptor are removed so that
+// new components can be mapped.  The contents of RAM are kept, use Fill to set them.
 func (m *MMU) Reset() {
-	for i := 0; i < len(m.eRAM); i++ {
-		m.eRAM[i] = 0x00
-	}
-	for i := 0; i < len(m.wRAM); i++ {
-		m.wRAM[i] = 0x00
-	}
-	for i := 0; i < len(m.zRAM); i++ {
-		m.zRAM[i] = 0x00
+	m.biosEnabled = m.boot != nil
+	m.mappings = nil
+	m.interceptor = nil
+	m.ie = 0x00
+	m.ifr = 0x00
+}
+
+// BootROMEnabled reports if the boot ROM is mapped over the cartridge ROM
+func (m *MMU) BootROMEnabled() bool {
+	return m.biosEnabled
+}
+
+// Map routes reads and writes for the inclusive address range start through end to rw.  The address passed to rw
+// is the full 16-bit address, not an offset into the range.  Mappings take precedence over the built in regions.
+func (m *MMU) Map(start, end uint16, rw ReadWriter) {
+	m.mappings = append(m.mappings, mapping{start: start, end: end, rw: rw})
+}
+
+// mapped returns the ReadWriter mapped to the given address, or nil if there isn't one
+func (m *MMU) mapped(a uint16) ReadWriter {
+	for _, mp := range m.mappings {
+		if a >= mp.start && a <= mp.end {
+			return mp.rw
+		}
 	}
-	m.biosEnabled = true
+	return nil
+}
+
+// Intercept installs an Interceptor that sees every read, pass nil to remove it
+func (m *MMU) Intercept(i Interceptor) {
+	m.interceptor = i
 }
 
 func (m *MMU) Read(a uint16) uint8 {
+	v := m.read(a)
+	if m.interceptor != nil {
+		v = m.interceptor.Intercept(a, v)
+	}
+	return v
+}
+
+func (m *MMU) read(a uint16) uint8 {
+	if isIO(a) {
+		if !m.cgb && cgbOnly(a) {
+			return 0xff
+		}
+		if a == 0xff0f {
+			// Interrupt flags
+			return m.ifr | m.readMask(a)
+		}
+		if rw := m.mapped(a); rw != nil {
+			return rw.Read(a) | m.readMask(a)
+		}
+		// Unmapped I/O registers read back with all bits set
+		return 0xff
+	}
+
+	if rw := m.mapped(a); rw != nil {
+		return rw.Read(a)
+	}
+
 	switch a & 0xf000 {
 	case 0x0000:
-		// ROM bank 0, except it returns the bios for the first 256 bytes during boot-up
-		if m.biosEnabled && a < 0x0100 {
-			return bios[a]
+		// ROM bank 0, except it returns the boot ROM for the addresses it covers during boot-up
+		if m.biosEnabled && m.boot.Maps(a) {
+			return m.boot[a]
 		}
 		fallthrough
 
-	case 0x1000, 0x2000, 0x3000:
-		// ROM banks 1 through 3
+	case 0x1000, 0x2000, 0x3000, 0x4000, 0x5000, 0x6000, 0x7000:
+		// ROM banks 0 and 1, addresses past the end of a short ROM read back with all bits set
+		if int(a) >= len(m.rom) {
+			return 0xff
+		}
 		return m.rom[a]
+
+	case 0xa000, 0xb000:
+		// External RAM
+		return m.eRAM[a-0xa000]
+
+	case 0xc000, 0xd000:
+		// Work RAM
+		return m.wRAM[a-0xc000]
+
+	case 0xf000:
+		// High RAM, 0xff80 ... 0xfffe
+		if a >= 0xff80 && a < 0xffff {
+			return m.zRAM[a-0xff80]
+		}
+
+		// Interrupt enable
+		if a == 0xffff {
+			return m.ie
+		}
 	}
 
 	panic("Invalid read")
 }
 
 func (m *MMU) Write(a uint16, v uint8) {
+	if !m.cgb && cgbOnly(a) {
+		return
+	}
+
+	if a == 0xff0f {
+		// Interrupt flags, only the lower 5 bits exist
+		m.ifr = v & 0x1f
+		return
+	}
+
+	if rw := m.mapped(a); rw != nil {
+		rw.Write(a, v)
+		return
+	}
+
 	switch a & 0xf000 {
+	case 0xa000, 0xb000:
+		// External RAM
+		m.eRAM[a-0xa000] = v
+		return
+
+	case 0xc000, 0xd000:
+		// Work RAM
+		m.wRAM[a-0xc000] = v
+		return
+
 	case 0xf000:
 		// 0xff00 ... 0xffff
-		switch a & 0xff {
-		case 0x50:
+		switch {
+		case a == 0xff50:
 			m.biosEnabled = false
+			return
+
+		case a >= 0xff80 && a < 0xffff:
+			m.zRAM[a-0xff80] = v
+			return
+
+		case a == 0xffff:
+			m.ie = v
+			return
+
+		case a >= 0xff00 && a < 0xff80:
+			// Writes to unmapped I/O registers are ignored
+			return
 		}
 	}
 
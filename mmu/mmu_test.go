@@ -0,0 +1,85 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIO(t *testing.T) {
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "ReadMask",
+			test: func(t *testing.T) {
+				// Unused bits read back as set on top of the mapped value
+				m := New(nil, nil, false)
+				m.Map(0xff00, 0xff7f, make(RAM, 0x10000))
+				require.EqualValues(t, 0x7e, m.Read(0xff02))
+				require.EqualValues(t, 0xf8, m.Read(0xff07))
+				require.EqualValues(t, 0x80, m.Read(0xff41))
+				require.EqualValues(t, 0x00, m.Read(0xff42))
+
+				// The CGB uses bit 1 of SC for the clock speed
+				m = New(nil, nil, true)
+				m.Map(0xff00, 0xff7f, make(RAM, 0x10000))
+				require.EqualValues(t, 0x7c, m.Read(0xff02))
+				require.EqualValues(t, 0x7e, m.Read(0xff4d))
+				require.EqualValues(t, 0xfe, m.Read(0xff4f))
+			},
+		},
+		{
+			name: "InterruptFlags",
+			test: func(t *testing.T) {
+				m := New(nil, nil, false)
+				m.Write(0xff0f, 0xff)
+				require.EqualValues(t, 0xff, m.Read(0xff0f))
+				m.Write(0xff0f, 0x01)
+				require.EqualValues(t, 0xe1, m.Read(0xff0f))
+			},
+		},
+		{
+			name: "CGBOnly",
+			test: func(t *testing.T) {
+				for _, a := range []uint16{0xff4d, 0xff4f, 0xff51, 0xff55, 0xff56, 0xff68, 0xff6b, 0xff6c, 0xff70, 0xff72, 0xff77} {
+					require.True(t, cgbOnly(a), "%#04x", a)
+				}
+				for _, a := range []uint16{0xff40, 0xff4c, 0xff50, 0xff57, 0xff67, 0xff71, 0xff78} {
+					require.False(t, cgbOnly(a), "%#04x", a)
+				}
+
+				// On the DMG the CGB registers read back as 0xff and ignore writes, even when mapped
+				ram := make(RAM, 0x10000)
+				m := New(nil, nil, false)
+				m.Map(0xff00, 0xff7f, ram)
+				m.Write(0xff70, 0x02)
+				require.EqualValues(t, 0x00, ram[0xff70])
+				require.EqualValues(t, 0xff, m.Read(0xff70))
+				m.Write(0xff47, 0xe4)
+				require.EqualValues(t, 0xe4, m.Read(0xff47))
+
+				// On the CGB they are accessible
+				m = New(nil, nil, true)
+				m.Map(0xff00, 0xff7f, ram)
+				m.Write(0xff70, 0x02)
+				require.EqualValues(t, 0x02, ram[0xff70])
+				require.EqualValues(t, 0xfa, m.Read(0xff70))
+			},
+		},
+		{
+			name: "Unmapped",
+			test: func(t *testing.T) {
+				// I/O registers without a component read back as 0xff and ignore writes
+				m := New(nil, nil, true)
+				m.Write(0xff03, 0x12)
+				require.EqualValues(t, 0xff, m.Read(0xff03))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
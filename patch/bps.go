@@ -0,0 +1,136 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+)
+
+// bpsMagic starts every BPS patch
+var bpsMagic = []byte("BPS1")
+
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// applyBPS applies a BPS patch, which builds the patched ROM from a list of actions that copy data from the original
+// ROM, the patch or the output written so far.
+// See: https://github.com/blakesmith/rombp/blob/master/docs/bps_spec.md
+func applyBPS(rom, patch []byte) ([]byte, error) {
+	if err := checkFooter(patch, rom, nil); err != nil {
+		return nil, err
+	}
+
+	r := &reader{data: patch[:len(patch)-12], pos: len(bpsMagic)}
+	sourceSize := r.varint()
+	targetSize := r.varint()
+	r.bytes(r.varint()) // metadata
+	if r.err != nil {
+		return nil, r.err
+	}
+	if targetSize > MaxSize {
+		return nil, fmt.Errorf("%w: target of %d bytes is too large", ErrCorrupt, targetSize)
+	}
+	if sourceSize != len(rom) {
+		return nil, ErrChecksum
+	}
+
+	out := make([]byte, 0, targetSize)
+	sourceOffset, targetOffset := 0, 0
+	for r.pos < len(r.data) {
+		command := r.varint()
+		length := command>>2 + 1
+		if r.err != nil {
+			return nil, r.err
+		}
+		if length <= 0 || len(out)+length > targetSize {
+			return nil, fmt.Errorf("%w: action writes past the end of the target", ErrCorrupt)
+		}
+
+		switch command & 3 {
+		case bpsSourceRead:
+			if len(out)+length > len(rom) {
+				return nil, ErrCorrupt
+			}
+			out = append(out, rom[len(out):len(out)+length]...)
+
+		case bpsTargetRead:
+			out = append(out, r.bytes(length)...)
+
+		case bpsSourceCopy:
+			sourceOffset += signed(r.varint())
+			if sourceOffset < 0 || sourceOffset+length > len(rom) {
+				return nil, ErrCorrupt
+			}
+			out = append(out, rom[sourceOffset:sourceOffset+length]...)
+			sourceOffset += length
+
+		case bpsTargetCopy:
+			targetOffset += signed(r.varint())
+			if targetOffset < 0 || targetOffset >= len(out) {
+				return nil, ErrCorrupt
+			}
+			// The copy may overlap the output it is writing, so it is done a byte at a time
+			for i := 0; i < length; i++ {
+				out = append(out, out[targetOffset])
+				targetOffset++
+			}
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	if len(out) != targetSize {
+		return nil, ErrCorrupt
+	}
+	if err := checkFooter(patch, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// signed decodes the relative offsets of the copy actions, the lowest bit is the sign
+func signed(v int) int {
+	if v&1 != 0 {
+		return -(v >> 1)
+	}
+	return v >> 1
+}
+
+// CreateBPS creates a BPS patch that turns source into target.  Bytes that are unchanged are read from the source and
+// everything else is stored in the patch, which is simple and works well for translations and romhacks that keep the
+// layout of the original ROM.
+func CreateBPS(source, target []byte) []byte {
+	b := &bytes.Buffer{}
+	b.Write(bpsMagic)
+	writeVarint(b, len(source))
+	writeVarint(b, len(target))
+	writeVarint(b, 0) // no metadata
+
+	for pos := 0; pos < len(target); {
+		// Find the run of bytes starting at pos that are either all unchanged or all changed
+		same := pos < len(source) && source[pos] == target[pos]
+		end := pos + 1
+		for end < len(target) && (end < len(source) && source[end] == target[end]) == same {
+			end++
+		}
+
+		length := end - pos
+		if same {
+			writeVarint(b, (length-1)<<2|bpsSourceRead)
+		} else {
+			writeVarint(b, (length-1)<<2|bpsTargetRead)
+			b.Write(target[pos:end])
+		}
+		pos = end
+	}
+
+	putLe32(b, crc32.ChecksumIEEE(source))
+	putLe32(b, crc32.ChecksumIEEE(target))
+	putLe32(b, crc32.ChecksumIEEE(b.Bytes()))
+	return b.Bytes()
+}
@@ -0,0 +1,56 @@
+package patch
+
+// ipsMagic starts every IPS patch
+var ipsMagic = []byte("PATCH")
+
+// applyIPS applies an IPS patch, which is a list of records that each overwrite a range of the ROM.  Records may grow
+// the ROM, and an optional truncation length may follow the EOF marker.
+// See: https://zerosoft.zophar.net/ips.php
+func applyIPS(rom, patch []byte) ([]byte, error) {
+	out := append([]byte(nil), rom...)
+	r := &reader{data: patch, pos: len(ipsMagic)}
+
+	for {
+		if string(r.bytes(3)) == "EOF" {
+			break
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		r.pos -= 3
+
+		offset := int(r.byte())<<16 | int(r.byte())<<8 | int(r.byte())
+		size := int(r.byte())<<8 | int(r.byte())
+
+		var data []byte
+		if size == 0 {
+			// A zero size is a run length encoded record
+			size = int(r.byte())<<8 | int(r.byte())
+			v := r.byte()
+			data = make([]byte, size)
+			for i := range data {
+				data[i] = v
+			}
+		} else {
+			data = r.bytes(size)
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		if end := offset + len(data); end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	// Some patches truncate the ROM after the EOF marker
+	if len(patch)-r.pos == 3 {
+		size := int(r.byte())<<16 | int(r.byte())<<8 | int(r.byte())
+		if size < len(out) {
+			out = out[:size]
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,147 @@
+// Package patch applies IPS, BPS and UPS patches to ROMs and creates BPS patches.
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrUnknownFormat is returned when a patch is not IPS, BPS or UPS
+	ErrUnknownFormat = errors.New("unknown patch format")
+	// ErrCorrupt is returned when a patch ends early or contains invalid data
+	ErrCorrupt = errors.New("corrupt patch")
+	// ErrChecksum is returned when a BPS or UPS patch is applied to the wrong ROM, or produced the wrong output
+	ErrChecksum = errors.New("patch checksum mismatch")
+)
+
+const (
+	// MaxSize is the largest ROM a patch may read or produce, the largest Game Boy cartridges are 8 MiB
+	MaxSize = 8 << 20
+	// maxVarintBytes is the longest variable length integer that is read, it holds values well beyond MaxSize
+	maxVarintBytes = 8
+)
+
+// Extensions are the file extensions of the supported patch formats
+var Extensions = []string{".ips", ".bps", ".ups"}
+
+// Apply applies an IPS, BPS or UPS patch to rom, the format is detected from the header of the patch.  The rom is not
+// modified, a new slice is returned.
+func Apply(rom, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, ipsMagic):
+		return applyIPS(rom, patch)
+	case bytes.HasPrefix(patch, bpsMagic):
+		return applyBPS(rom, patch)
+	case bytes.HasPrefix(patch, upsMagic):
+		return applyUPS(rom, patch)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// IsPatch reports if the path has the extension of a supported patch format
+func IsPatch(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// reader reads the bytes of a patch, reads past the end set err instead of panicking
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *reader) byte() uint8 {
+	if r.pos >= len(r.data) {
+		r.err = ErrCorrupt
+		return 0
+	}
+	v := r.data[r.pos]
+	r.pos++
+	return v
+}
+
+func (r *reader) bytes(n int) []byte {
+	if n < 0 || r.pos+n > len(r.data) {
+		r.err = ErrCorrupt
+		return nil
+	}
+	v := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return v
+}
+
+// varint reads the variable length integers used by BPS and UPS.  Integers longer than maxVarintBytes or larger than
+// an int32 can hold set err, no valid patch needs them.
+func (r *reader) varint() int {
+	var data, shift uint64 = 0, 1
+	for i := 0; r.err == nil; i++ {
+		if i == maxVarintBytes {
+			r.err = ErrCorrupt
+			return 0
+		}
+		x := r.byte()
+		data += uint64(x&0x7f) * shift
+		if x&0x80 != 0 {
+			break
+		}
+		shift <<= 7
+		data += shift
+	}
+	if data > math.MaxInt32 {
+		r.err = ErrCorrupt
+		return 0
+	}
+	return int(data)
+}
+
+// writeVarint appends a variable length integer as used by BPS and UPS
+func writeVarint(b *bytes.Buffer, data int) {
+	for {
+		x := uint8(data & 0x7f)
+		data >>= 7
+		if data == 0 {
+			b.WriteByte(0x80 | x)
+			return
+		}
+		b.WriteByte(x)
+		data--
+	}
+}
+
+// checkFooter validates the three CRC32 values at the end of a BPS or UPS patch
+func checkFooter(patch, input, output []byte) error {
+	if len(patch) < 12 {
+		return ErrCorrupt
+	}
+	footer := patch[len(patch)-12:]
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != le32(footer[8:]) {
+		return fmt.Errorf("%w: patch is damaged", ErrChecksum)
+	}
+	if input != nil && crc32.ChecksumIEEE(input) != le32(footer[0:]) {
+		return fmt.Errorf("%w: patch is for a different ROM", ErrChecksum)
+	}
+	if output != nil && crc32.ChecksumIEEE(output) != le32(footer[4:]) {
+		return fmt.Errorf("%w: patched ROM does not match", ErrChecksum)
+	}
+	return nil
+}
+
+func le32(b []byte) uint32 {
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24
+}
+
+func putLe32(b *bytes.Buffer, v uint32) {
+	b.Write([]byte{uint8(v), uint8(v >> 8), uint8(v >> 16), uint8(v >> 24)})
+}
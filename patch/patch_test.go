@@ -0,0 +1,127 @@
+package patch
+
+import (
+	"bytes"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatch(t *testing.T) {
+	source := []byte("the original rom contents")
+	target := []byte("the patched rom contents, which are longer")
+
+	var tests = []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "BPS",
+			test: func(t *testing.T) {
+				p := CreateBPS(source, target)
+				out, err := Apply(source, p)
+				require.NoError(t, err)
+				require.Equal(t, target, out)
+
+				_, err = Apply([]byte("some other rom contents!!"), p)
+				require.ErrorIs(t, err, ErrChecksum)
+
+				p[5] ^= 0xff
+				_, err = Apply(source, p)
+				require.ErrorIs(t, err, ErrChecksum)
+			},
+		},
+		{
+			name: "IPS",
+			test: func(t *testing.T) {
+				p := []byte("PATCH")
+				// Overwrite "original" with "ORIGINAL"
+				p = append(p, 0x00, 0x00, 0x04, 0x00, 0x08)
+				p = append(p, "ORIGINAL"...)
+				// Run length encoded, 3 bytes of '!' past the end of the rom
+				p = append(p, 0x00, 0x00, 0x19, 0x00, 0x00, 0x00, 0x03, '!')
+				p = append(p, "EOF"...)
+
+				out, err := Apply(source, p)
+				require.NoError(t, err)
+				require.Equal(t, "the ORIGINAL rom contents!!!", string(out))
+
+				_, err = Apply(source, p[:12])
+				require.ErrorIs(t, err, ErrCorrupt)
+			},
+		},
+		{
+			name: "UPS",
+			test: func(t *testing.T) {
+				in := []byte{0x01, 0x02, 0x03, 0x04}
+				out := []byte{0x01, 0x07, 0x03, 0x04, 0x05}
+
+				b := &bytes.Buffer{}
+				b.Write(upsMagic)
+				writeVarint(b, len(in))
+				writeVarint(b, len(out))
+				// Skip one byte, XOR the second, then skip to the new last byte
+				writeVarint(b, 1)
+				b.Write([]byte{0x02 ^ 0x07, 0x00})
+				writeVarint(b, 1)
+				b.Write([]byte{0x05, 0x00})
+				putLe32(b, crc32.ChecksumIEEE(in))
+				putLe32(b, crc32.ChecksumIEEE(out))
+				putLe32(b, crc32.ChecksumIEEE(b.Bytes()))
+
+				patched, err := Apply(in, b.Bytes())
+				require.NoError(t, err)
+				require.Equal(t, out, patched)
+			},
+		},
+		{
+			name: "Malformed",
+			test: func(t *testing.T) {
+				// patch returns a patch with the body and a valid footer, so that the body is what gets rejected
+				patch := func(magic []byte, body ...byte) []byte {
+					b := &bytes.Buffer{}
+					b.Write(magic)
+					b.Write(body)
+					putLe32(b, crc32.ChecksumIEEE(source))
+					putLe32(b, crc32.ChecksumIEEE(target))
+					putLe32(b, crc32.ChecksumIEEE(b.Bytes()))
+					return b.Bytes()
+				}
+				size := uint8(0x80 | len(source))
+
+				for _, p := range []struct {
+					name  string
+					patch []byte
+				}{
+					// A varint that never ends within the maximum length
+					{"BPSLongVarint", patch(bpsMagic, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80)},
+					{"UPSLongVarint", patch(upsMagic, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80)},
+					// A varint that is larger than an int32
+					{"BPSHugeVarint", patch(bpsMagic, 0x7f, 0x7f, 0x7f, 0x7f, 0x7f, 0x80)},
+					// A target or output size larger than MaxSize
+					{"BPSHugeTarget", patch(bpsMagic, size, 0x7f, 0x7f, 0x7e, 0x87, 0x80)},
+					{"UPSHugeOutput", patch(upsMagic, size, 0x7f, 0x7f, 0x7e, 0x87)},
+					// Reading 2 bytes from the source into a target of 1 byte
+					{"BPSPastTarget", patch(bpsMagic, size, 0x81, 0x80, 0x84)},
+					// Copying from before the start of the target
+					{"BPSTargetCopy", patch(bpsMagic, size, 0x82, 0x80, 0x81, 0x83)},
+				} {
+					_, err := Apply(source, p.patch)
+					require.ErrorIs(t, err, ErrCorrupt, p.name)
+				}
+			},
+		},
+		{
+			name: "Unknown",
+			test: func(t *testing.T) {
+				_, err := Apply(source, []byte("nope"))
+				require.ErrorIs(t, err, ErrUnknownFormat)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
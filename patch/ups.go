@@ -0,0 +1,55 @@
+package patch
+
+import "fmt"
+
+// upsMagic starts every UPS patch
+var upsMagic = []byte("UPS1")
+
+// applyUPS applies an UPS patch, which is a list of hunks that are XORed into the ROM
+// See: http://individual.utoronto.ca/dmeunier/ups-spec.pdf
+func applyUPS(rom, patch []byte) ([]byte, error) {
+	if err := checkFooter(patch, rom, nil); err != nil {
+		return nil, err
+	}
+
+	r := &reader{data: patch[:len(patch)-12], pos: len(upsMagic)}
+	inputSize := r.varint()
+	outputSize := r.varint()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if outputSize > MaxSize {
+		return nil, fmt.Errorf("%w: output of %d bytes is too large", ErrCorrupt, outputSize)
+	}
+	if inputSize != len(rom) {
+		return nil, ErrChecksum
+	}
+
+	out := make([]byte, outputSize)
+	copy(out, rom)
+
+	pos := 0
+	for r.pos < len(r.data) {
+		pos += r.varint()
+		for {
+			x := r.byte()
+			if r.err != nil {
+				return nil, r.err
+			}
+			if x == 0 {
+				// Hunks are terminated by a 0 byte, which also skips an unchanged byte
+				pos++
+				break
+			}
+			if pos < len(out) {
+				out[pos] ^= x
+			}
+			pos++
+		}
+	}
+
+	if err := checkFooter(patch, nil, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
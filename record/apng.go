@@ -0,0 +1,141 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// The delay of each APNG frame in seconds is apngDelayNum / apngDelayDen, the closest fraction to the length of a
+// Game Boy frame that fits the 16 bit fields
+const (
+	apngDelayNum = 400
+	apngDelayDen = 23891
+)
+
+// pngSignature starts every PNG file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+
+// apngWriter writes an animated PNG.  Each frame is encoded as a PNG with image/png and its image data is copied into
+// the animation.  The number of frames is written once the recording is closed.
+type apngWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	// actl is the offset of the acTL chunk in the file
+	actl int64
+	// sequence numbers the fcTL and fdAT chunks
+	sequence uint32
+	frames   uint32
+	buf      bytes.Buffer
+}
+
+func newAPNG(path string) (*apngWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &apngWriter{file: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (a *apngWriter) frame(f *gpu.Frame) error {
+	a.buf.Reset()
+	if err := png.Encode(&a.buf, f.Image(1)); err != nil {
+		return err
+	}
+	data := a.buf.Bytes()[len(pngSignature):]
+
+	for len(data) > 0 {
+		length := binary.BigEndian.Uint32(data)
+		kind, chunk := string(data[4:8]), data[8:8+length]
+		data = data[12+length:]
+
+		switch kind {
+		case "IHDR":
+			// The header of the first frame is the header of the animation, followed by the animation control with
+			// room for the number of frames
+			if a.frames == 0 {
+				a.w.Write(pngSignature)
+				a.writeChunk("IHDR", chunk)
+				a.actl = int64(len(pngSignature) + 12 + len(chunk))
+				a.writeChunk("acTL", make([]byte, 8))
+			}
+			a.writeFrameControl()
+
+		case "IDAT":
+			// The data of the first frame is also the default image, the data of the others is numbered
+			if a.frames == 0 {
+				a.writeChunk("IDAT", chunk)
+				continue
+			}
+			fdat := make([]byte, 4+len(chunk))
+			binary.BigEndian.PutUint32(fdat, a.sequence)
+			copy(fdat[4:], chunk)
+			a.sequence++
+			a.writeChunk("fdAT", fdat)
+		}
+	}
+
+	a.frames++
+	// An empty write returns the first error of the earlier writes
+	_, err := a.w.Write(nil)
+	return err
+}
+
+// writeFrameControl writes the fcTL chunk that starts a frame, which covers the whole image and replaces the previous
+// frame
+func (a *apngWriter) writeFrameControl() {
+	fctl := make([]byte, 26)
+	binary.BigEndian.PutUint32(fctl[0:], a.sequence)
+	binary.BigEndian.PutUint32(fctl[4:], gpu.Width)
+	binary.BigEndian.PutUint32(fctl[8:], gpu.Height)
+	binary.BigEndian.PutUint16(fctl[20:], apngDelayNum)
+	binary.BigEndian.PutUint16(fctl[22:], apngDelayDen)
+	a.sequence++
+	a.writeChunk("fcTL", fctl)
+}
+
+// writeChunk writes a PNG chunk with its length and CRC, errors are kept by the bufio.Writer
+func (a *apngWriter) writeChunk(kind string, data []byte) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	copy(header[4:], kind)
+	a.w.Write(header[:])
+	a.w.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	binary.Write(a.w, binary.BigEndian, crc.Sum32())
+}
+
+func (a *apngWriter) close() error {
+	a.writeChunk("IEND", nil)
+	err := a.w.Flush()
+	if err == nil && a.frames > 0 {
+		err = a.writeFrameCount()
+	}
+	if e := a.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// writeFrameCount fills in the number of frames in the acTL chunk, the animation loops forever
+func (a *apngWriter) writeFrameCount() error {
+	chunk := make([]byte, 20)
+	binary.BigEndian.PutUint32(chunk[0:], 8)
+	copy(chunk[4:], "acTL")
+	binary.BigEndian.PutUint32(chunk[8:], a.frames)
+	binary.BigEndian.PutUint32(chunk[16:], crc32.ChecksumIEEE(chunk[4:16]))
+
+	if _, err := a.file.Seek(a.actl, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := a.file.Write(chunk)
+	return err
+}
@@ -0,0 +1,117 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"os"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// gifHeaderSize is the size of the header and logical screen descriptor that image/gif writes for a GIF without a
+// global color table
+const gifHeaderSize = 13
+
+// gifWriter writes an animated GIF.  Each frame is encoded as a GIF with image/gif and its image block is copied into
+// the animation, so frames are not kept in memory.
+type gifWriter struct {
+	file   *os.File
+	w      *bufio.Writer
+	frames int
+	buf    bytes.Buffer
+}
+
+func newGIF(path string) (*gifWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &gifWriter{file: f, w: bufio.NewWriter(f)}
+	// The logical screen has no global color table, every frame has its own.  The NETSCAPE2.0 extension loops the
+	// animation forever.
+	g.w.WriteString("GIF89a")
+	binary.Write(g.w, binary.LittleEndian, [2]uint16{gpu.Width, gpu.Height})
+	g.w.Write([]byte{0x00, 0x00, 0x00})
+	g.w.Write([]byte{0x21, 0xff, 0x0b})
+	g.w.WriteString("NETSCAPE2.0")
+	g.w.Write([]byte{0x03, 0x01, 0x00, 0x00, 0x00})
+	return g, nil
+}
+
+// frame writes every other frame, see gifDelay
+func (g *gifWriter) frame(f *gpu.Frame) error {
+	frame := g.frames
+	g.frames++
+	if frame%2 != 0 {
+		return nil
+	}
+
+	g.buf.Reset()
+	single := &gif.GIF{Image: []*image.Paletted{paletted(f)}, Delay: []int{gifDelay(frame)}}
+	if err := gif.EncodeAll(&g.buf, single); err != nil {
+		return err
+	}
+	// Copy the image block, between the header and the trailer
+	data := g.buf.Bytes()
+	g.w.Write(data[gifHeaderSize : len(data)-1])
+
+	// An empty write returns the first error of the earlier writes
+	_, err := g.w.Write(nil)
+	return err
+}
+
+// gifDelay returns the delay in hundredths of a second of the GIF frame that starts at a Game Boy frame.  Viewers show
+// delays below 2 hundredths as 10, so a GIF frame is written for every other Game Boy frame.  The delays alternate
+// between 3 and 4 so that the animation keeps the Game Boy's frame rate over time.
+func gifDelay(frame int) int {
+	at := func(frame int) int {
+		return (frame*frameCycles*100 + clockRate/2) / clockRate
+	}
+	return at(frame+2) - at(frame)
+}
+
+// paletted converts a frame to a paletted image with the colors it uses, frames with more than 256 colors are mapped
+// to the nearest colors of the Plan 9 palette
+func paletted(f *gpu.Frame) *image.Paletted {
+	bounds := image.Rect(0, 0, gpu.Width, gpu.Height)
+	indexes := map[gpu.Color]uint8{}
+	var colors color.Palette
+	for y := range f {
+		for _, c := range f[y] {
+			if _, ok := indexes[c]; ok {
+				continue
+			}
+			if len(colors) == 256 {
+				img := image.NewPaletted(bounds, palette.Plan9)
+				draw.Draw(img, bounds, f.Image(1), image.Point{}, draw.Src)
+				return img
+			}
+			indexes[c] = uint8(len(colors))
+			colors = append(colors, c)
+		}
+	}
+
+	img := image.NewPaletted(bounds, colors)
+	for y := range f {
+		for x, c := range f[y] {
+			img.SetColorIndex(x, y, indexes[c])
+		}
+	}
+	return img
+}
+
+func (g *gifWriter) close() error {
+	g.w.WriteByte(0x3b)
+	err := g.w.Flush()
+	if e := g.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+// Package record saves the frames and audio of the emulator to video files.  The frames are the exact frames the GPU
+// completes at the Game Boy's refresh rate of 59.73 Hz, so recordings are the same on every run and don't depend on
+// the speed of the host.
+package record
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+var (
+	// ErrFormat is returned for a file extension that isn't a known recording format
+	ErrFormat = errors.New("unknown recording format")
+	// ErrEmpty is returned by Close when no frames were recorded, the files of the recording are removed
+	ErrEmpty = errors.New("no frames were recorded")
+)
+
+// The Game Boy shows a frame every 70224 cycles of its 4194304 Hz clock
+const (
+	clockRate   = 4194304
+	frameCycles = 70224
+)
+
+// Format is the kind of file a recording is written to
+type Format int
+
+const (
+	// APNG is a lossless animated PNG, for short clips
+	APNG Format = iota
+	// GIF is an animated GIF, for short clips.  It is lossless unless a frame has more than 256 colors, which only
+	// happens on the CGB with palettes that change mid-frame.  Only every other frame is kept since GIF viewers don't
+	// play faster than 50 Hz.
+	GIF
+	// Y4M is raw YUV 4:4:4 video with the audio in a WAV file next to it, for muxing and encoding with ffmpeg
+	Y4M
+)
+
+var formatNames = map[Format]string{
+	APNG: "apng",
+	GIF:  "gif",
+	Y4M:  "y4m",
+}
+
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// FormatOf returns the format for the extension of a path: .png or .apng, .gif, or .y4m
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".apng":
+		return APNG, nil
+	case ".gif":
+		return GIF, nil
+	case ".y4m":
+		return Y4M, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrFormat, filepath.Ext(path))
+}
+
+// encoder writes the frames of one format
+type encoder interface {
+	frame(f *gpu.Frame) error
+	close() error
+}
+
+// Recorder implements emulator.VideoSink and emulator.AudioSink by writing everything it receives to a file.  Errors
+// stop the recording and are returned by Close.
+type Recorder struct {
+	format Format
+	video  encoder
+	audio  *wavWriter
+	frames int
+	err    error
+	// paths are the files of the recording, they are removed if nothing was recorded
+	paths []string
+}
+
+// Create starts a recording to path in the format given by its extension, see FormatOf.  For Y4M the audio is written
+// to a file with the extension replaced by .wav.
+func Create(path string) (*Recorder, error) {
+	format, err := FormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{format: format, paths: []string{path}}
+	switch format {
+	case APNG:
+		r.video, err = newAPNG(path)
+	case GIF:
+		r.video, err = newGIF(path)
+	case Y4M:
+		if r.video, err = newY4M(path); err != nil {
+			return nil, err
+		}
+		wav := strings.TrimSuffix(path, filepath.Ext(path)) + ".wav"
+		if r.audio, err = newWAV(wav); err != nil {
+			r.video.close()
+			return nil, err
+		}
+		r.paths = append(r.paths, wav)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Format returns the format of the recording
+func (r *Recorder) Format() Format {
+	return r.format
+}
+
+// Frames returns the number of frames recorded
+func (r *Recorder) Frames() int {
+	return r.frames
+}
+
+// Frame implements emulator.VideoSink
+func (r *Recorder) Frame(f *gpu.Frame) {
+	if r.err != nil {
+		return
+	}
+	r.err = r.video.frame(f)
+	r.frames++
+}
+
+// Samples implements emulator.AudioSink, the samples are dropped unless the format keeps audio
+func (r *Recorder) Samples(samples []int16) {
+	if r.err != nil || r.audio == nil {
+		return
+	}
+	r.err = r.audio.samples(samples)
+}
+
+// Close finishes the files of the recording.  The audio is padded with silence to the length of the video, so the
+// two stay in sync when they are muxed.  A recording without frames is not a valid file in every format, its files
+// are removed and ErrEmpty is returned.
+func (r *Recorder) Close() error {
+	err := r.err
+	if r.audio != nil {
+		if e := r.audio.close(r.frames); err == nil {
+			err = e
+		}
+	}
+	if e := r.video.close(); err == nil {
+		err = e
+	}
+
+	if r.frames == 0 {
+		for _, path := range r.paths {
+			if e := os.Remove(path); err == nil {
+				err = e
+			}
+		}
+		if err == nil {
+			err = ErrEmpty
+		}
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/borgstrom/ebgb/emulator"
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// testFrame returns a frame filled with a single color
+func testFrame(c gpu.Color) *gpu.Frame {
+	f := &gpu.Frame{}
+	for y := range f {
+		for x := range f[y] {
+			f[y][x] = c
+		}
+	}
+	return f
+}
+
+// record writes frames of the given colors to a new recording in a temporary directory and returns its path
+func record(t *testing.T, name string, colors ...gpu.Color) string {
+	path := filepath.Join(t.TempDir(), name)
+	r, err := Create(path)
+	require.NoError(t, err)
+	for _, c := range colors {
+		r.Frame(testFrame(c))
+	}
+	require.Equal(t, len(colors), r.Frames())
+	require.NoError(t, r.Close())
+	return path
+}
+
+func TestRecord(t *testing.T) {
+	red, green, blue := gpu.RGB(31, 0, 0), gpu.RGB(0, 31, 0), gpu.RGB(0, 0, 31)
+
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{
+			name: "FormatOf",
+			test: func(t *testing.T) {
+				for path, format := range map[string]Format{"a.png": APNG, "a.APNG": APNG, "a.gif": GIF, "a.y4m": Y4M} {
+					f, err := FormatOf(path)
+					require.NoError(t, err)
+					require.Equal(t, format, f)
+				}
+				_, err := FormatOf("a.mp4")
+				require.ErrorIs(t, err, ErrFormat)
+			},
+		},
+		{
+			name: "APNG",
+			test: func(t *testing.T) {
+				data, err := os.ReadFile(record(t, "clip.png", red, green, blue))
+				require.NoError(t, err)
+
+				// Viewers without APNG support show the first frame
+				img, err := png.Decode(bytes.NewReader(data))
+				require.NoError(t, err)
+				require.Equal(t, gpu.Width, img.Bounds().Dx())
+				require.Equal(t, gpu.Height, img.Bounds().Dy())
+				r, g, b, _ := img.At(0, 0).RGBA()
+				require.Equal(t, [3]uint32{0xffff, 0, 0}, [3]uint32{r, g, b})
+
+				// The acTL chunk follows IHDR and holds the number of frames, there is an fcTL chunk for each
+				actl := bytes.Index(data, []byte("acTL"))
+				require.Equal(t, 37, actl)
+				require.EqualValues(t, 3, binary.BigEndian.Uint32(data[actl+4:]))
+				require.Equal(t, 3, bytes.Count(data, []byte("fcTL")))
+				require.Equal(t, 2, bytes.Count(data, []byte("fdAT")))
+			},
+		},
+		{
+			name: "GIF",
+			test: func(t *testing.T) {
+				f, err := os.Open(record(t, "clip.gif", red, green, blue))
+				require.NoError(t, err)
+				defer f.Close()
+
+				// Every other frame is kept
+				anim, err := gif.DecodeAll(f)
+				require.NoError(t, err)
+				require.Len(t, anim.Image, 2)
+				require.Equal(t, []int{3, 4}, anim.Delay)
+				require.Zero(t, anim.LoopCount)
+				r, g, b, _ := anim.Image[1].At(0, 0).RGBA()
+				require.Equal(t, [3]uint32{0, 0, 0xffff}, [3]uint32{r, g, b})
+			},
+		},
+		{
+			name: "GIFDelay",
+			test: func(t *testing.T) {
+				// 5974 frames last 100.02 seconds at 59.73 Hz, every other frame is kept and none is shorter than the
+				// 2 hundredths that viewers play
+				total := 0
+				for frame := 0; frame < 5974; frame += 2 {
+					d := gifDelay(frame)
+					require.Contains(t, []int{3, 4}, d)
+					total += d
+				}
+				require.Equal(t, 10002, total)
+			},
+		},
+		{
+			name: "Empty",
+			test: func(t *testing.T) {
+				// Nothing is left behind by a recording without frames
+				for _, name := range []string{"clip.png", "clip.gif", "clip.y4m"} {
+					dir := t.TempDir()
+					r, err := Create(filepath.Join(dir, name))
+					require.NoError(t, err)
+					require.ErrorIs(t, r.Close(), ErrEmpty)
+
+					entries, err := os.ReadDir(dir)
+					require.NoError(t, err)
+					require.Empty(t, entries, name)
+				}
+			},
+		},
+		{
+			name: "Y4M",
+			test: func(t *testing.T) {
+				path := record(t, "clip.y4m", red, green)
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+
+				header := "YUV4MPEG2 W160 H144 F4194304:70224 Ip A1:1 C444 XCOLORRANGE=FULL\n"
+				require.Equal(t, header, string(data[:len(header)]))
+				require.Len(t, data, len(header)+2*(len("FRAME\n")+3*gpu.Width*gpu.Height))
+
+				// The audio is silence as long as the video
+				wav, err := os.ReadFile(filepath.Join(filepath.Dir(path), "clip.wav"))
+				require.NoError(t, err)
+				samples := 2 * frameCycles * emulator.SampleRate / clockRate
+				require.Len(t, wav, wavHeaderSize+samples*4)
+				require.Equal(t, "RIFF", string(wav[:4]))
+				require.EqualValues(t, samples*4, binary.LittleEndian.Uint32(wav[40:]))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, test.test)
+	}
+}
@@ -0,0 +1,78 @@
+package record
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/borgstrom/ebgb/emulator"
+)
+
+// wavHeaderSize is the size of the RIFF header, the fmt chunk and the header of the data chunk
+const wavHeaderSize = 44
+
+// wavWriter writes 16 bit stereo PCM audio at emulator.SampleRate to a WAV file.  The sizes in the header are filled
+// in once the recording is closed.
+type wavWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	// count is the number of samples written for each channel
+	count int
+}
+
+func newWAV(path string) (*wavWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &wavWriter{file: f, w: bufio.NewWriter(f)}
+	w.w.Write(w.header())
+	return w, nil
+}
+
+// header returns the WAV header for the samples written so far
+func (w *wavWriter) header() []byte {
+	const channels, bytesPerSample = 2, 2
+	size := uint32(w.count * channels * bytesPerSample)
+
+	h := make([]byte, wavHeaderSize)
+	copy(h[0:], "RIFF")
+	binary.LittleEndian.PutUint32(h[4:], wavHeaderSize-8+size)
+	copy(h[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(h[16:], 16)
+	binary.LittleEndian.PutUint16(h[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(h[22:], channels)
+	binary.LittleEndian.PutUint32(h[24:], emulator.SampleRate)
+	binary.LittleEndian.PutUint32(h[28:], emulator.SampleRate*channels*bytesPerSample)
+	binary.LittleEndian.PutUint16(h[32:], channels*bytesPerSample)
+	binary.LittleEndian.PutUint16(h[34:], bytesPerSample*8)
+	copy(h[36:], "data")
+	binary.LittleEndian.PutUint32(h[40:], size)
+	return h
+}
+
+// samples writes interleaved stereo samples
+func (w *wavWriter) samples(samples []int16) error {
+	w.count += len(samples) / 2
+	return binary.Write(w.w, binary.LittleEndian, samples)
+}
+
+// close pads the audio with silence to the length of the given number of frames and fills in the header
+func (w *wavWriter) close(frames int) error {
+	expected := int(int64(frames) * frameCycles * emulator.SampleRate / clockRate)
+	if missing := expected - w.count; missing > 0 {
+		w.samples(make([]int16, missing*2))
+	}
+
+	err := w.w.Flush()
+	if err == nil {
+		if _, err = w.file.Seek(0, io.SeekStart); err == nil {
+			_, err = w.file.Write(w.header())
+		}
+	}
+	if e := w.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
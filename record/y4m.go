@@ -0,0 +1,54 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// y4mWriter writes raw YUV4MPEG2 video with full range 4:4:4 chroma, so that no color is lost before encoding
+type y4mWriter struct {
+	file  *os.File
+	w     *bufio.Writer
+	plane [3][gpu.Width * gpu.Height]uint8
+}
+
+func newY4M(path string) (*y4mWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	y := &y4mWriter{file: f, w: bufio.NewWriter(f)}
+	fmt.Fprintf(y.w, "YUV4MPEG2 W%d H%d F%d:%d Ip A1:1 C444 XCOLORRANGE=FULL\n", gpu.Width, gpu.Height, clockRate,
+		frameCycles)
+	return y, nil
+}
+
+func (y *y4mWriter) frame(f *gpu.Frame) error {
+	for row := range f {
+		for x, c := range f[row] {
+			r, g, b := c.RGB8()
+			i := row*gpu.Width + x
+			y.plane[0][i], y.plane[1][i], y.plane[2][i] = color.RGBToYCbCr(r, g, b)
+		}
+	}
+
+	y.w.WriteString("FRAME\n")
+	for _, p := range y.plane {
+		if _, err := y.w.Write(p[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (y *y4mWriter) close() error {
+	err := y.w.Flush()
+	if e := y.file.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+//go:build !headless
+// +build !headless
+
+package main
+
+import (
+	"context"
+
+	"github.com/borgstrom/ebgb/emulator"
+	"github.com/borgstrom/ebgb/frontend/filter"
+	"github.com/borgstrom/ebgb/frontend/sdl"
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// runWindow runs the emulator in an SDL window until it is closed or ctx is cancelled
+func runWindow(ctx context.Context, e *emulator.Emulator, scale int, fullscreen bool, f filter.Filter, blend bool,
+	correction gpu.Correction, correctShots bool) error {
+	return sdl.Run(ctx, e, sdl.Options{
+		Scale:              scale,
+		Fullscreen:         fullscreen,
+		Filter:             f,
+		Blend:              blend,
+		Correction:         correction,
+		CorrectScreenshots: correctShots,
+	})
+}
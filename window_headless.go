@@ -0,0 +1,20 @@
+//go:build headless
+// +build headless
+
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/borgstrom/ebgb/emulator"
+	"github.com/borgstrom/ebgb/frontend/filter"
+	"github.com/borgstrom/ebgb/gpu"
+)
+
+// runWindow fails when built with the headless tag, which leaves out the SDL frontend so that neither cgo nor SDL2
+// are needed.  Only --headless runs are possible.
+func runWindow(ctx context.Context, e *emulator.Emulator, scale int, fullscreen bool, f filter.Filter, blend bool,
+	correction gpu.Correction, correctShots bool) error {
+	return errors.New("built without a window, use --headless")
+}